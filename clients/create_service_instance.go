@@ -15,7 +15,8 @@ import (
 )
 
 // CreateServiceInstance create Cloud Foundry service instance
-func CreateServiceInstance(cliConnection plugin.CliConnection, spaceGUID string, servicePlan models.CFServicePlan, parameters interface{}, name string) (*models.CFServiceInstance, error) {
+// with optional metadata labels
+func CreateServiceInstance(cliConnection plugin.CliConnection, spaceGUID string, servicePlan models.CFServicePlan, parameters interface{}, name string, labels map[string]string) (*models.CFServiceInstance, error) {
 	var apiEndpoint string
 	var accessToken string
 	var request *http.Request
@@ -24,6 +25,7 @@ func CreateServiceInstance(cliConnection plugin.CliConnection, spaceGUID string,
 	var err error
 	var url string
 	var serviceParameters string
+	var serviceMetadata string
 	var body []byte
 	var job models.CFJob
 	var link models.CFLink
@@ -48,12 +50,19 @@ func CreateServiceInstance(cliConnection plugin.CliConnection, spaceGUID string,
 	} else {
 		serviceParameters = ""
 	}
+	if len(labels) > 0 {
+		labelsBytes, err := json.Marshal(labels)
+		if err != nil {
+			return nil, err
+		}
+		serviceMetadata = "\"metadata\":{\"labels\":" + string(labelsBytes) + "},"
+	}
 	if name == "" {
 		name = servicePlan.Name + "-" + t
 	} else if len(name) > 1 && name[len(name)-1:] == "-" {
 		name = name + servicePlan.Name + "-" + t
 	}
-	body = []byte("{" + serviceParameters + "\"type\":\"managed\",\"name\":\"" + name + "\",\"relationships\":{\"space\":{\"data\":{\"guid\":\"" + spaceGUID + "\"}},\"service_plan\":{\"data\":{\"guid\":\"" + servicePlan.GUID + "\"}}}}")
+	body = []byte("{" + serviceParameters + serviceMetadata + "\"type\":\"managed\",\"name\":\"" + name + "\",\"relationships\":{\"space\":{\"data\":{\"guid\":\"" + spaceGUID + "\"}},\"service_plan\":{\"data\":{\"guid\":\"" + servicePlan.GUID + "\"}}}}")
 
 	log.Tracef("Making request to: %s %s\n", url, string(body))
 	request, err = http.NewRequest("POST", url, bytes.NewBuffer(body))
@@ -0,0 +1,66 @@
+package clients
+
+import (
+	models "cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// GetFileContentIfNoneMatch get HTML5 applications file content as stream,
+// if its ETag differs from the given one (conditional GET). If ETag is empty,
+// content is requested unconditionally. Reader of content fails, if response
+// body does not match content length, and should be closed
+func GetFileContentIfNoneMatch(serviceURL string, filePath string, accessToken string, appHostGUID string, etag string) (models.HTML5ApplicationFileContent, error) {
+	var request *http.Request
+	var response *http.Response
+	var err error
+	var html5URL string
+
+	html5URL = serviceURL + filePath
+
+	if etag != "" {
+		log.Tracef("Making conditional request to: %s (If-None-Match: %s)\n", html5URL, etag)
+	} else {
+		log.Tracef("Making request to: %s\n", html5URL)
+	}
+
+	client, err := GetDefaultClient()
+	if err != nil {
+		return models.HTML5ApplicationFileContent{}, err
+	}
+	request, err = http.NewRequest("GET", html5URL, nil)
+	if err != nil {
+		return models.HTML5ApplicationFileContent{}, err
+	}
+	request.Header.Add("Authorization", "Bearer "+accessToken)
+	if appHostGUID != "" {
+		request.Header.Add("x-app-host-id", appHostGUID)
+	}
+	if etag != "" {
+		request.Header.Add("If-None-Match", etag)
+	}
+	response, err = client.Do(request)
+	if err != nil {
+		return models.HTML5ApplicationFileContent{}, err
+	}
+	log.Trace(log.Response{Head: response})
+
+	// Check response code
+	if etag != "" && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		return models.HTML5ApplicationFileContent{NotModified: true, ETag: etag}, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		return models.HTML5ApplicationFileContent{}, errors.New(response.Status + ": " + string(body))
+	}
+
+	return models.HTML5ApplicationFileContent{
+		Content: &contentLengthReader{body: response.Body, expected: response.ContentLength},
+		Size:    response.ContentLength,
+		ETag:    response.Header.Get("Etag"),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+)
+
+// Maximum size of error response body included in error message
+const maxErrorBodySize = 4096
+
+// contentLengthReader fails, if stream is shorter or longer
+// than expected content length
+type contentLengthReader struct {
+	body     io.ReadCloser
+	expected int64
+	read     int64
+}
+
+// Read reads from response body and validates its length at the end
+func (r *contentLengthReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.read += int64(n)
+	if r.expected >= 0 && r.read > r.expected {
+		return n, fmt.Errorf("Response body is longer than Content-Length %d", r.expected)
+	}
+	if err == io.EOF && r.expected >= 0 && r.read != r.expected {
+		return n, fmt.Errorf("Response body has %d bytes, but Content-Length is %d: %w", r.read, r.expected, io.ErrUnexpectedEOF)
+	}
+	return n, err
+}
+
+// Close closes response body
+func (r *contentLengthReader) Close() error {
+	return r.body.Close()
+}
+
+// GetFileContentReader get HTML5 applications file content as stream.
+// Returns content length (-1, if unknown) and reader of response body,
+// which fails if body does not match content length. Reader should be closed
+func GetFileContentReader(serviceURL string, filePath string, accessToken string, appHostGUID string) (io.ReadCloser, int64, error) {
+	fileContent, err := GetFileContentIfNoneMatch(serviceURL, filePath, accessToken, appHostGUID, "")
+	if err != nil {
+		return nil, 0, err
+	}
+	return fileContent.Content, fileContent.Size, nil
+}
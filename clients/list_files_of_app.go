@@ -4,7 +4,7 @@ import (
 	models "cf-html5-apps-repo-cli-plugin/clients/models"
 	"cf-html5-apps-repo-cli-plugin/log"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
 )
@@ -49,7 +49,7 @@ func ListFilesOfApp(serviceURL string, appKey string, accessToken string, appHos
 
 	// Check response code
 	if response.StatusCode != 200 {
-		return html5Response, fmt.Errorf(string(body))
+		return html5Response, errors.New(string(body))
 	}
 
 	// Parse response JSON
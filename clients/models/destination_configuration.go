@@ -62,7 +62,7 @@ func (dc *DestinationConfiguration) UnmarshalJSON(data []byte) error {
 			dc.ProxyType = value
 		case "tokenServiceURL":
 			dc.TokenServiceURL = value
-		case "TokenServiceURLType":
+		case "tokenServiceURLType", "TokenServiceURLType":
 			dc.TokenServiceURLType = value
 		case "clientId":
 			dc.ClientID = value
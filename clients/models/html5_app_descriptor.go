@@ -45,12 +45,19 @@ func (s *HTML5AppDescriptorRouteScope) UnmarshalJSON(data []byte) error {
 			}
 		}
 	case map[string]interface{}:
+		s.Verbs = make(map[string][]string)
 		if value["default"] != nil {
 			switch defaultValue := value["default"].(type) {
 			case string:
 				s.Default = []string{defaultValue}
-			case []string:
-				s.Default = defaultValue
+			case []interface{}:
+				s.Default = make([]string, 0)
+				for _, item := range defaultValue {
+					switch itemValue := item.(type) {
+					case string:
+						s.Default = append(s.Default, itemValue)
+					}
+				}
 			}
 			delete(value, "default")
 		}
@@ -1,7 +1,13 @@
 package models
 
-// HTML5ApplicationFileContent application file content
+import "io"
+
+// HTML5ApplicationFileContent application file content stream
 type HTML5ApplicationFileContent struct {
-	Content []byte
-	Error   error
+	// Reader of file content (nil, if not modified). Should be closed
+	Content io.ReadCloser
+	// Content length (-1, if unknown)
+	Size        int64
+	ETag        string
+	NotModified bool
 }
@@ -0,0 +1,73 @@
+package clients
+
+import (
+	"bytes"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// UpdateServiceInstance update parameters of Cloud Foundry service instance
+func UpdateServiceInstance(cliConnection plugin.CliConnection, serviceInstanceGUID string, parameters interface{}) error {
+	var apiEndpoint string
+	var accessToken string
+	var request *http.Request
+	var response *http.Response
+	var err error
+	var url string
+	var body []byte
+
+	apiEndpoint, err = cliConnection.ApiEndpoint()
+	if err != nil {
+		return err
+	}
+	accessToken, err = cliConnection.AccessToken()
+	if err != nil {
+		return err
+	}
+	url = apiEndpoint + "/v3/service_instances/" + serviceInstanceGUID
+	body, err = json.Marshal(map[string]interface{}{"parameters": parameters})
+	if err != nil {
+		return err
+	}
+
+	log.Tracef("Making request to: %s %s\n", url, string(body))
+	request, err = http.NewRequest("PATCH", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", accessToken)
+
+	client, err := GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	response, err = client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err = io.ReadAll(response.Body)
+	log.Trace(log.Response{Head: response, Body: body})
+	if err != nil {
+		return err
+	}
+
+	// Service instance updated synchronously
+	if response.StatusCode == 200 {
+		return nil
+	}
+	if response.StatusCode != 202 {
+		return fmt.Errorf("Could not update service instance: [%d] %s", response.StatusCode, string(body[:]))
+	}
+
+	// Pool job
+	_, err = PollJob(cliConnection, response.Header.Get("Location"))
+	return err
+}
@@ -0,0 +1,58 @@
+package clients
+
+import (
+	"bytes"
+	models "cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UpdateSubaccountDestination update destination service subaccount destination
+func UpdateSubaccountDestination(serviceURL string, accessToken string, destination models.DestinationConfiguration) error {
+	var err error
+	var request *http.Request
+	var response *http.Response
+	var destinationsURL string
+	var payload []byte
+	var body []byte
+
+	log.Tracef("Marshaling destination configuration: %+v\n", log.Sensitive{Data: destination})
+	payload, err = destination.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	log.Tracef("Destination configuration JSON: %s\n", log.Sensitive{Data: string(payload)})
+
+	destinationsURL = serviceURL + "/destination-configuration/v1/subaccountDestinations"
+	log.Tracef("Making request to: %s\n", destinationsURL)
+	request, err = http.NewRequest("PUT", destinationsURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client, err := GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	response, err = client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err = io.ReadAll(response.Body)
+	log.Trace(log.Response{Head: response, Body: body})
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode > 204 {
+		return fmt.Errorf("Could not update destination: [%s] %s", response.Status, string(body))
+	}
+
+	return nil
+}
@@ -1,8 +1,8 @@
 package clients
 
 import (
-	"bytes"
 	"cf-html5-apps-repo-cli-plugin/log"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,54 +10,39 @@ import (
 	"net/http"
 	"net/textproto"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
+// UploadProgress callback, which is called each time part of ZIP file
+// is sent to html5-apps-repo service
+type UploadProgress func(zipFile string, sent int64, total int64)
+
 // UploadAppHost upload ZIP files with HTML5 applications to html5-apps-repo service
-func UploadAppHost(serviceURL string, zipFiles []string, accessToken string) error {
+func UploadAppHost(serviceURL string, zipFiles []string, accessToken string, progress UploadProgress) error {
 	var html5URL string
 	var err error
 
 	html5URL = serviceURL + "/applications/content/"
 
-	// Multipart body writer
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
-
-	// Add zip files as parts
-	for _, zipFile := range zipFiles {
-		log.Tracef("Adding '%s' as part to multipart request\n", zipFile)
-		// Read application archive
-		file, err := os.Open(zipFile)
-		if err != nil {
-			return err
-		}
-		fileContents, err := ioutil.ReadAll(file)
-		if err != nil {
-			return err
-		}
-		fi, err := file.Stat()
-		if err != nil {
-			return err
-		}
-		file.Close()
-
-		// Write file contents as part
-		h := make(textproto.MIMEHeader)
-		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, "apps", fi.Name()))
-		h.Set("Content-Type", "application/zip")
-		part, err := writer.CreatePart(h)
-		if err != nil {
-			return err
-		}
-		part.Write(fileContents)
+	// Calculate size of request body without reading files
+	contentLength, boundary, err := getMultipartContentLength(zipFiles)
+	if err != nil {
+		return err
 	}
+	log.Tracef("Multipart request body size: %d bytes\n", contentLength)
 
-	// Close request body
-	err = writer.Close()
+	// Stream multipart body through pipe
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	err = writer.SetBoundary(boundary)
 	if err != nil {
 		return err
 	}
+	go func() {
+		pipeWriter.CloseWithError(writeMultipartBody(writer, zipFiles, progress))
+	}()
+	defer pipeReader.Close()
 
 	// Make request
 	log.Tracef("Making request to: %s\n", html5URL)
@@ -65,21 +50,22 @@ func UploadAppHost(serviceURL string, zipFiles []string, accessToken string) err
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest("PUT", html5URL, body)
+	request, err := http.NewRequest("PUT", html5URL, pipeReader)
 	if err != nil {
 		return err
 	}
+	request.ContentLength = contentLength
 	request.Header.Add("Authorization", "Bearer "+accessToken)
-	request.Header.Add("Content-Type", "multipart/form-data; boundary="+writer.Boundary())
+	request.Header.Add("Content-Type", "multipart/form-data; boundary="+boundary)
 	response, err := client.Do(request)
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
 	if response.StatusCode == 201 {
 		log.Tracef("Successfully uploaded: %+v\n", zipFiles)
 	} else {
 		// Get response body
-		defer response.Body.Close()
 		body, _ := io.ReadAll(response.Body)
 		bodyString := string(body)
 		log.Tracef("Could not upload files: %+v. Response: [%d] %s\n", zipFiles, response.StatusCode, bodyString)
@@ -87,7 +73,7 @@ func UploadAppHost(serviceURL string, zipFiles []string, accessToken string) err
 		// Handle client errors (HTTP 400)
 		if response.StatusCode == 400 && idx >= 0 {
 			bodyString = bodyString[idx+1:]
-			return fmt.Errorf(bodyString)
+			return errors.New(bodyString)
 		}
 		// Return error
 		return fmt.Errorf("[%d] %s", response.StatusCode, bodyString)
@@ -95,3 +81,96 @@ func UploadAppHost(serviceURL string, zipFiles []string, accessToken string) err
 
 	return nil
 }
+
+// getMultipartContentLength calculates length of multipart request body
+// with ZIP files as parts, and returns it together with used boundary
+func getMultipartContentLength(zipFiles []string) (int64, string, error) {
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	for _, zipFile := range zipFiles {
+		fi, err := os.Stat(zipFile)
+		if err != nil {
+			return 0, "", err
+		}
+		_, err = writer.CreatePart(getZipPartHeader(fi.Name()))
+		if err != nil {
+			return 0, "", err
+		}
+		counter.count += fi.Size()
+	}
+	err := writer.Close()
+	if err != nil {
+		return 0, "", err
+	}
+	return counter.count, writer.Boundary(), nil
+}
+
+// writeMultipartBody writes ZIP files as parts of multipart request body
+// one by one, without reading whole files into memory
+func writeMultipartBody(writer *multipart.Writer, zipFiles []string, progress UploadProgress) error {
+	for _, zipFile := range zipFiles {
+		log.Tracef("Adding '%s' as part to multipart request\n", zipFile)
+		file, err := os.Open(zipFile)
+		if err != nil {
+			return err
+		}
+		fi, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		// Write file contents as part
+		part, err := writer.CreatePart(getZipPartHeader(fi.Name()))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		var target io.Writer = part
+		if progress != nil {
+			target = &progressWriter{writer: part, zipFile: zipFile, total: fi.Size(), progress: progress}
+		}
+		_, err = io.Copy(target, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Close request body
+	return writer.Close()
+}
+
+// getZipPartHeader returns MIME header of multipart request part with ZIP file
+func getZipPartHeader(fileName string) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, "apps", filepath.Base(fileName)))
+	h.Set("Content-Type", "application/zip")
+	return h
+}
+
+// countingWriter counts bytes written to it
+type countingWriter struct {
+	count int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.count += int64(len(p))
+	return ioutil.Discard.Write(p)
+}
+
+// progressWriter reports number of bytes written to underlying writer
+type progressWriter struct {
+	writer   io.Writer
+	zipFile  string
+	sent     int64
+	total    int64
+	progress UploadProgress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.sent += int64(n)
+	w.progress(w.zipFile, w.sent, w.total)
+	return n, err
+}
@@ -0,0 +1,397 @@
+package commands
+
+import (
+	clients "cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/plugin"
+	"gopkg.in/yaml.v2"
+)
+
+// Default name of deployment descriptor file
+const deployDescriptorFileName = "html5-deploy.yaml"
+
+// Fields of deployment descriptor. Nested maps list fields of objects
+var deployDescriptorFields = map[string]interface{}{
+	"apps":      nil,
+	"recursive": nil,
+	"depth":     nil,
+	"appHost": map[string]interface{}{
+		"name":   nil,
+		"params": nil,
+	},
+	"destination": map[string]interface{}{
+		"level":              nil,
+		"instance":           nil,
+		"service":            nil,
+		"securityDescriptor": nil,
+		"name":               nil,
+		"description":        nil,
+		"properties":         nil,
+	},
+	"runtime":        nil,
+	"allowShadowing": nil,
+}
+
+// ApplyCommand push HTML5 applications according to deployment descriptor
+type ApplyCommand struct {
+	PushCommand
+}
+
+// DeployDescriptor deployment descriptor (html5-deploy.yaml)
+type DeployDescriptor struct {
+	// Paths or glob patterns of application folders and archives,
+	// relative to deployment descriptor
+	Apps []string `yaml:"apps"`
+	// Look for applications in subdirectories recursively
+	Recursive bool `yaml:"recursive,omitempty"`
+	// Maximum depth of recursive search
+	Depth *int `yaml:"depth,omitempty"`
+	// Target app-host service instance
+	AppHost DeployDescriptorAppHost `yaml:"appHost"`
+	// Destination to access applications
+	Destination *DeployDescriptorDestination `yaml:"destination,omitempty"`
+	// Runtime for which conventional URLs of applications are shown
+	Runtime string `yaml:"runtime,omitempty"`
+	// Push applications, which names collide with other applications
+	AllowShadowing bool `yaml:"allowShadowing,omitempty"`
+}
+
+// DeployDescriptorAppHost target app-host service instance
+type DeployDescriptorAppHost struct {
+	// Name of app-host service instance. Created, if does not exist
+	Name string `yaml:"name"`
+	// Parameters of app-host service instance, if new one is created:
+	// object or path to JSON file relative to deployment descriptor
+	Params interface{} `yaml:"params,omitempty"`
+}
+
+// DeployDescriptorDestination destination to access applications
+type DeployDescriptorDestination struct {
+	// Level of destination: subaccount or instance
+	Level string `yaml:"level"`
+	// Name of destination service instance for instance level destination
+	Instance string `yaml:"instance,omitempty"`
+	// Name of business service instance, which credentials are used in destination
+	Service string `yaml:"service,omitempty"`
+	// Path to XSUAA security descriptor relative to deployment descriptor
+	SecurityDescriptor string `yaml:"securityDescriptor,omitempty"`
+	// Name of destination
+	Name string `yaml:"name,omitempty"`
+	// Description of destination
+	Description string `yaml:"description,omitempty"`
+	// Additional properties of destination
+	Properties map[string]string `yaml:"properties,omitempty"`
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *ApplyCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "html5-apply",
+		HelpText: "Push HTML5 applications according to deployment descriptor",
+		UsageDetails: plugin.Usage{
+			Usage: "cf html5-apply [-f DEPLOYMENT_DESCRIPTOR] [--dry-run]",
+			Options: map[string]string{
+				"-file,-f": "Path to deployment descriptor. Default value is 'html5-deploy.yaml'",
+				"-dry-run": "Print deployment plan without creating service instances, service keys, destinations or uploading applications",
+			},
+		},
+	}
+}
+
+// Execute executes plugin command
+func (c *ApplyCommand) Execute(args []string) ExecutionStatus {
+	log.Tracef("Executing command '%s': args: '%v'\n", c.Name, args)
+
+	// Parse arguments
+	flagSet := flag.NewFlagSet("html5-apply", flag.ContinueOnError)
+	fileFlag := flagSet.String("file", "", "deployment descriptor")
+	fileFlagAlias := flagSet.String("f", "", "deployment descriptor")
+	dryRunFlag := flagSet.Bool("dry-run", false, "print deployment plan without pushing")
+	err := flagSet.Parse(args)
+	if err != nil {
+		ui.Failed("Could not parse arguments: %+v", err)
+		return Failure
+	}
+	if flagSet.NArg() > 0 {
+		ui.Failed("Incorrect number of arguments passed. See [cf html5-apply --help] for more details")
+		return Failure
+	}
+
+	// Normalize aliases
+	fileName := *fileFlagAlias
+	if *fileFlag != "" {
+		fileName = *fileFlag
+	}
+	if fileName == "" {
+		fileName = deployDescriptorFileName
+	}
+	log.Tracef("Deployment descriptor: %v\n", fileName)
+
+	return c.ApplyDeployDescriptor(fileName, *dryRunFlag)
+}
+
+// ApplyDeployDescriptor push HTML5 applications according to deployment descriptor
+func (c *ApplyCommand) ApplyDeployDescriptor(fileName string, dryRun bool) ExecutionStatus {
+	// Read and validate deployment descriptor
+	descriptor, err := loadDeployDescriptor(fileName)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	problems := validateDeployDescriptor(descriptor)
+	if len(problems) > 0 {
+		ui.Failed("Deployment descriptor %s is not valid:\n%s", fileName, strings.Join(problems, "\n"))
+		return Failure
+	}
+
+	// Push options
+	dir := filepath.Dir(fileName)
+	options, err := getDeployDescriptorOptions(descriptor, dir)
+	if err != nil {
+		ui.Failed("Deployment descriptor %s is not valid: %s", fileName, err.Error())
+		return Failure
+	}
+	appPaths := make([]string, 0)
+	for _, appPath := range descriptor.Apps {
+		if !filepath.IsAbs(appPath) {
+			appPath = filepath.Join(dir, appPath)
+		}
+		appPaths = append(appPaths, appPath)
+	}
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	// Find target app-host
+	log.Tracef("Looking for app-host service instance '%s'\n", descriptor.AppHost.Name)
+	appHostGUID := ""
+	serviceInstances, err := clients.GetServiceInstancesByNamePrefix(c.CliConnection, context.SpaceID, descriptor.AppHost.Name)
+	if err != nil {
+		ui.Failed("Could not get service instances: %+v", err)
+		return Failure
+	}
+	for _, serviceInstance := range serviceInstances {
+		if serviceInstance.Name == descriptor.AppHost.Name {
+			appHostGUID = serviceInstance.GUID
+			break
+		}
+	}
+	if appHostGUID == "" {
+		log.Tracef("App-host service instance '%s' does not exist and will be created\n", descriptor.AppHost.Name)
+	} else {
+		log.Tracef("App-host service instance '%s' exists: %s\n", descriptor.AppHost.Name, appHostGUID)
+		if options.AppHostParams != nil {
+			ui.Warn("App-host service instance %s already exists. Parameters are used only for new service instances",
+				terminal.EntityNameColor(descriptor.AppHost.Name))
+		}
+	}
+
+	// Show what would change
+	options.DryRun = true
+	status := c.PushHTML5Applications(appPaths, appHostGUID, options)
+	if status == Failure || dryRun {
+		return status
+	}
+
+	// Apply changes
+	options.DryRun = false
+	return c.PushHTML5Applications(appPaths, appHostGUID, options)
+}
+
+// loadDeployDescriptor reads deployment descriptor
+func loadDeployDescriptor(fileName string) (DeployDescriptor, error) {
+	var descriptor DeployDescriptor
+
+	log.Tracef("Reading deployment descriptor '%s'\n", fileName)
+	fileContents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return descriptor, fmt.Errorf("Could not read deployment descriptor %s: %s", fileName, err.Error())
+	}
+	err = yaml.Unmarshal(fileContents, &descriptor)
+	if err != nil {
+		return descriptor, fmt.Errorf("Could not parse deployment descriptor %s: %s", fileName, err.Error())
+	}
+
+	// Check for unknown (e.g. misspelled) fields
+	var fields map[string]interface{}
+	err = yaml.Unmarshal(fileContents, &fields)
+	if err != nil {
+		return descriptor, fmt.Errorf("Could not parse deployment descriptor %s: %s", fileName, err.Error())
+	}
+	unknownFields := getUnknownFields("", fields, deployDescriptorFields)
+	if len(unknownFields) > 0 {
+		return descriptor, fmt.Errorf("Could not parse deployment descriptor %s: unknown fields: %s", fileName, strings.Join(unknownFields, ", "))
+	}
+	log.Tracef("Deployment descriptor: %+v\n", descriptor)
+
+	return descriptor, nil
+}
+
+// getUnknownFields returns paths of fields, which are not defined in
+// deployment descriptor
+func getUnknownFields(prefix string, fields map[string]interface{}, knownFields map[string]interface{}) []string {
+	unknownFields := make([]string, 0)
+	for name, value := range fields {
+		knownField, ok := knownFields[name]
+		if !ok {
+			unknownFields = append(unknownFields, prefix+name)
+			continue
+		}
+		nestedKnownFields, isObject := knownField.(map[string]interface{})
+		nestedFields, ok := value.(map[interface{}]interface{})
+		if !isObject || !ok {
+			continue
+		}
+		nested := make(map[string]interface{})
+		for key, item := range nestedFields {
+			nested[fmt.Sprintf("%v", key)] = item
+		}
+		unknownFields = append(unknownFields, getUnknownFields(prefix+name+"/", nested, nestedKnownFields)...)
+	}
+	sort.Strings(unknownFields)
+	return unknownFields
+}
+
+// validateDeployDescriptor returns list of deployment descriptor problems
+func validateDeployDescriptor(descriptor DeployDescriptor) []string {
+	problems := make([]string, 0)
+	if len(descriptor.Apps) == 0 {
+		problems = append(problems, "apps: at least one application path or glob pattern is required")
+	}
+	for idx, appPath := range descriptor.Apps {
+		if strings.TrimSpace(appPath) == "" {
+			problems = append(problems, fmt.Sprintf("apps[%d]: path should not be empty", idx))
+		}
+	}
+	if descriptor.Depth != nil && *descriptor.Depth < 0 {
+		problems = append(problems, "depth: should not be negative")
+	}
+	if descriptor.Depth != nil && !descriptor.Recursive {
+		problems = append(problems, "depth: can only be used together with 'recursive: true'")
+	}
+	if descriptor.AppHost.Name == "" {
+		problems = append(problems, "appHost/name: name of app-host service instance is required")
+	}
+	if descriptor.AppHost.Params != nil {
+		switch descriptor.AppHost.Params.(type) {
+		case string, map[interface{}]interface{}:
+		default:
+			problems = append(problems, "appHost/params: should be an object or a path to JSON file")
+		}
+	}
+	if descriptor.Destination != nil {
+		switch descriptor.Destination.Level {
+		case "subaccount":
+			if descriptor.Destination.Instance != "" {
+				problems = append(problems, "destination/instance: can only be used with 'level: instance'")
+			}
+		case "instance":
+			if descriptor.Destination.Instance == "" {
+				problems = append(problems, "destination/instance: name of destination service instance is required for 'level: instance'")
+			}
+		case "":
+			if descriptor.Destination.Service == "" {
+				problems = append(problems, "destination/level: level or service is required")
+			}
+			if descriptor.Destination.Instance != "" {
+				problems = append(problems, "destination/instance: can only be used with 'level: instance'")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("destination/level: value '%s' should be one of: subaccount, instance", descriptor.Destination.Level))
+		}
+		properties := make([]string, 0)
+		for key, value := range descriptor.Destination.Properties {
+			properties = append(properties, key+"="+value)
+		}
+		if _, err := parseDestinationProperties(properties); err != nil {
+			problems = append(problems, "destination/properties: "+err.Error())
+		}
+		if descriptor.Destination.SecurityDescriptor != "" && descriptor.Destination.Level != "instance" &&
+			(descriptor.Destination.Level != "subaccount" || descriptor.Destination.Service != "") {
+			problems = append(problems, "destination/securityDescriptor: can only be used with 'level: instance', or with 'level: subaccount' without service")
+		}
+	}
+	return problems
+}
+
+// getDeployDescriptorOptions converts deployment descriptor to html5-push options
+func getDeployDescriptorOptions(descriptor DeployDescriptor, dir string) (PushOptions, error) {
+	var err error
+
+	options := PushOptions{
+		Runtime:        descriptor.Runtime,
+		Recursive:      descriptor.Recursive,
+		Depth:          defaultSearchDepth,
+		AppHostName:    descriptor.AppHost.Name,
+		AllowShadowing: descriptor.AllowShadowing,
+	}
+	if descriptor.Depth != nil {
+		options.Depth = *descriptor.Depth
+	}
+	if descriptor.Destination != nil {
+		options.BusinessService = descriptor.Destination.Service
+		if descriptor.Destination.Level == "instance" {
+			options.DestinationInstance = descriptor.Destination.Instance
+		} else if descriptor.Destination.Level == "subaccount" && descriptor.Destination.Service == "" {
+			options.Destination = true
+		}
+		options.DestinationName = descriptor.Destination.Name
+		options.DestinationDescription = descriptor.Destination.Description
+		options.DestinationProperties = descriptor.Destination.Properties
+		options.SecurityDescriptor = descriptor.Destination.SecurityDescriptor
+		if options.SecurityDescriptor != "" && !filepath.IsAbs(options.SecurityDescriptor) {
+			options.SecurityDescriptor = filepath.Join(dir, options.SecurityDescriptor)
+		}
+	}
+
+	// App-host parameters
+	switch params := descriptor.AppHost.Params.(type) {
+	case string:
+		if !filepath.IsAbs(params) {
+			params = filepath.Join(dir, params)
+		}
+		options.AppHostParams, err = parseJSONParameter(params)
+		if err != nil {
+			return options, errors.New("appHost/params: " + err.Error())
+		}
+	case map[interface{}]interface{}:
+		options.AppHostParams = convertYAMLValue(params)
+	}
+
+	return options, nil
+}
+
+// convertYAMLValue converts maps with interface{} keys produced by YAML
+// parser to maps with string keys, which can be marshalled to JSON
+func convertYAMLValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range typedValue {
+			result[fmt.Sprintf("%v", key)] = convertYAMLValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typedValue))
+		for idx, item := range typedValue {
+			result[idx] = convertYAMLValue(item)
+		}
+		return result
+	}
+	return value
+}
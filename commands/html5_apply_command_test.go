@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestGetUnknownFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			content:  "",
+			expected: []string{},
+		},
+		{
+			name: "known fields",
+			content: `
+apps: [app1, app2]
+recursive: true
+depth: 2
+appHost:
+  name: my-app-host
+  params:
+    sizeLimit: 10
+destination:
+  level: instance
+  instance: my-destination
+  properties:
+    anyName: value
+runtime: launchpad
+allowShadowing: true
+`,
+			expected: []string{},
+		},
+		{
+			name: "unknown fields",
+			content: `
+app: [app1]
+appHost:
+  nmae: my-app-host
+destination:
+  levels: subaccount
+  properties:
+    unknown: ignored
+`,
+			expected: []string{"app", "appHost/nmae", "destination/levels"},
+		},
+		{
+			name: "scalar instead of object",
+			content: `
+apps: [app1]
+appHost: my-app-host
+`,
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		var fields map[string]interface{}
+		if err := yaml.Unmarshal([]byte(test.content), &fields); err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+		unknownFields := getUnknownFields("", fields, deployDescriptorFields)
+		if !reflect.DeepEqual(unknownFields, test.expected) {
+			t.Errorf("%s: getUnknownFields returned %v, expected %v", test.name, unknownFields, test.expected)
+		}
+	}
+}
+
+func TestValidateDeployDescriptor(t *testing.T) {
+	depth := 1
+	negativeDepth := -1
+	tests := []struct {
+		name       string
+		descriptor DeployDescriptor
+		expected   []string
+	}{
+		{
+			name: "valid",
+			descriptor: DeployDescriptor{
+				Apps:    []string{"app"},
+				AppHost: DeployDescriptorAppHost{Name: "my-app-host", Params: map[interface{}]interface{}{"sizeLimit": 10}},
+			},
+			expected: []string{},
+		},
+		{
+			name:       "empty",
+			descriptor: DeployDescriptor{},
+			expected: []string{
+				"apps: at least one application path or glob pattern is required",
+				"appHost/name: name of app-host service instance is required",
+			},
+		},
+		{
+			name: "invalid apps and params",
+			descriptor: DeployDescriptor{
+				Apps:    []string{"app", " "},
+				AppHost: DeployDescriptorAppHost{Name: "my-app-host", Params: []interface{}{1}},
+			},
+			expected: []string{
+				"apps[1]: path should not be empty",
+				"appHost/params: should be an object or a path to JSON file",
+			},
+		},
+		{
+			name: "params file",
+			descriptor: DeployDescriptor{
+				Apps:    []string{"app"},
+				AppHost: DeployDescriptorAppHost{Name: "my-app-host", Params: "params.json"},
+			},
+			expected: []string{},
+		},
+		{
+			name: "recursive depth",
+			descriptor: DeployDescriptor{
+				Apps:      []string{"."},
+				Recursive: true,
+				Depth:     &depth,
+				AppHost:   DeployDescriptorAppHost{Name: "my-app-host"},
+			},
+			expected: []string{},
+		},
+		{
+			name: "invalid depth",
+			descriptor: DeployDescriptor{
+				Apps:    []string{"."},
+				Depth:   &negativeDepth,
+				AppHost: DeployDescriptorAppHost{Name: "my-app-host"},
+			},
+			expected: []string{
+				"depth: should not be negative",
+				"depth: can only be used together with 'recursive: true'",
+			},
+		},
+		{
+			name: "instance destination",
+			descriptor: DeployDescriptor{
+				Apps:        []string{"app"},
+				AppHost:     DeployDescriptorAppHost{Name: "my-app-host"},
+				Destination: &DeployDescriptorDestination{Level: "instance", Instance: "my-destination", SecurityDescriptor: "xs-security.json"},
+			},
+			expected: []string{},
+		},
+		{
+			name: "instance destination without instance",
+			descriptor: DeployDescriptor{
+				Apps:        []string{"app"},
+				AppHost:     DeployDescriptorAppHost{Name: "my-app-host"},
+				Destination: &DeployDescriptorDestination{Level: "instance"},
+			},
+			expected: []string{
+				"destination/instance: name of destination service instance is required for 'level: instance'",
+			},
+		},
+		{
+			name: "subaccount destination with instance and service",
+			descriptor: DeployDescriptor{
+				Apps:        []string{"app"},
+				AppHost:     DeployDescriptorAppHost{Name: "my-app-host"},
+				Destination: &DeployDescriptorDestination{Level: "subaccount", Instance: "my-destination", Service: "my-service", SecurityDescriptor: "xs-security.json"},
+			},
+			expected: []string{
+				"destination/instance: can only be used with 'level: instance'",
+				"destination/securityDescriptor: can only be used with 'level: instance', or with 'level: subaccount' without service",
+			},
+		},
+		{
+			name: "destination without level",
+			descriptor: DeployDescriptor{
+				Apps:        []string{"app"},
+				AppHost:     DeployDescriptorAppHost{Name: "my-app-host"},
+				Destination: &DeployDescriptorDestination{Instance: "my-destination"},
+			},
+			expected: []string{
+				"destination/level: level or service is required",
+				"destination/instance: can only be used with 'level: instance'",
+			},
+		},
+		{
+			name: "destination with invalid level and properties",
+			descriptor: DeployDescriptor{
+				Apps:        []string{"app"},
+				AppHost:     DeployDescriptorAppHost{Name: "my-app-host"},
+				Destination: &DeployDescriptorDestination{Level: "space", Properties: map[string]string{"URL": "https://example.com"}},
+			},
+			expected: []string{
+				"destination/level: value 'space' should be one of: subaccount, instance",
+				"destination/properties: Destination property 'URL' is defined by html5-push and can not be changed",
+			},
+		},
+	}
+	for _, test := range tests {
+		problems := validateDeployDescriptor(test.descriptor)
+		if !reflect.DeepEqual(problems, test.expected) {
+			t.Errorf("%s: validateDeployDescriptor returned %q, expected %q", test.name, problems, test.expected)
+		}
+	}
+}
@@ -153,7 +153,7 @@ func (c *HTML5Command) GetDestinationContext(context Context, destinationInstanc
 	// Create instance of 'lite' plan if needed
 	if len(destinationServiceInstances) == 0 {
 		log.Tracef("Creating service instance of 'destination' service 'lite' plan\n")
-		destinationServiceInstance, err := clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *liteServicePlan, nil, "")
+		destinationServiceInstance, err := clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *liteServicePlan, nil, "", nil)
 		if err != nil {
 			return destinationContext, fmt.Errorf("Could not create service instance of 'destination' service 'lite' plan: %s", err.Error())
 		}
@@ -304,7 +304,7 @@ func (c *HTML5Command) GetHTML5Context(context Context) (HTML5Context, error) {
 	var appRuntimeServiceInstance *models.CFServiceInstance
 	if len(validAppRuntimeServiceInstances) == 0 {
 		log.Tracef("Creating service instance of %s service app-runtime plan\n", serviceName)
-		appRuntimeServiceInstance, err = clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *appRuntimeServicePlan, nil, "")
+		appRuntimeServiceInstance, err = clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *appRuntimeServicePlan, nil, "", nil)
 		if err != nil {
 			return html5Context, errors.New("Could not create service instance of app-runtime plan: " + err.Error())
 		}
@@ -450,7 +450,7 @@ type DestinationContext struct {
 type stringSlice []string
 
 func (i *stringSlice) String() string {
-	return fmt.Sprintf("%d", *i)
+	return strings.Join(*i, ",")
 }
 
 func (i *stringSlice) Set(value string) error {
@@ -0,0 +1,347 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Delay before the first retry of failed file download,
+// doubled with each subsequent retry
+var downloadRetryDelay = time.Second
+
+// DownloadFailure file, which could not be downloaded
+type DownloadFailure struct {
+	// Path of file, starting from /<appName-appVersion>
+	Path string
+	// Error of the last attempt
+	Error error
+}
+
+// DownloadError files, which could not be downloaded
+type DownloadError struct {
+	// Failed files
+	Failures []DownloadFailure
+	// Total number of files
+	Total int
+}
+
+// Error returns description of failed files
+func (e *DownloadError) Error() string {
+	failures := make([]string, 0)
+	for _, failure := range e.Failures {
+		failures = append(failures, fmt.Sprintf("%s: %+v", failure.Path, failure.Error))
+	}
+	return fmt.Sprintf("Could not download %d of %d files:\n%s", len(e.Failures), e.Total, strings.Join(failures, "\n"))
+}
+
+// downloadAppFiles downloads files of applications deployed to app-host
+// into directory. Files are saved by their path, starting from /<appName-appVersion>.
+// If resume is true, files already downloaded are skipped. Returns number of
+// skipped files
+func downloadAppFiles(html5Context HTML5Context, appHostGUID string, files []models.HTML5ApplicationFile, dir string, resume bool) (int, error) {
+	// Normalize (remove trailing slash)
+	if string(dir[len(dir)-1]) == slash {
+		dir = string(dir[:len(dir)-1])
+	}
+
+	// Skip files already downloaded
+	skipped := 0
+	if resume {
+		var err error
+		total := len(files)
+		files, err = getMissingAppFiles(html5Context, appHostGUID, files, dir)
+		if err != nil {
+			return skipped, err
+		}
+		skipped = total - len(files)
+		log.Tracef("Skipping %d files already downloaded\n", skipped)
+	}
+
+	// Save files
+	return skipped, fetchAppFiles(html5Context, appHostGUID, files, func(file models.HTML5ApplicationFile, content io.Reader) error {
+		return saveAppFile(dir, file.FilePath, content)
+	})
+}
+
+// getMissingAppFiles returns files of applications deployed to app-host, which
+// do not exist in directory, or which size or ETag differ from local files
+func getMissingAppFiles(html5Context HTML5Context, appHostGUID string, files []models.HTML5ApplicationFile, dir string) ([]models.HTML5ApplicationFile, error) {
+	missingFiles := make([]models.HTML5ApplicationFile, 0)
+
+	// Rate limiter for cuncurrent connections
+	rateLimiter := make(chan int, maxConcurrentConnections)
+
+	// Get size and ETag of files existing locally
+	metas := make([]chan models.HTML5ApplicationFileMetadata, len(files))
+	requested := 0
+	for idx, file := range files {
+		info, err := os.Stat(dir + strings.Replace(file.FilePath, "/", slash, -1))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		metas[idx] = make(chan models.HTML5ApplicationFileMetadata, 1)
+		requested++
+		go func(filePath string, idx int, metaChannel chan models.HTML5ApplicationFileMetadata) {
+			rateLimiter <- idx
+			clients.GetFileMeta(
+				*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+				filePath,
+				html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+				appHostGUID,
+				metaChannel)
+		}(file.FilePath, idx, metas[idx])
+	}
+	fileMetas := make([]models.HTML5ApplicationFileMetadata, len(files))
+	for i := 0; i < requested; i++ {
+		var idx int = <-rateLimiter
+		fileMetas[idx] = <-metas[idx]
+	}
+
+	// Compare with local files
+	for idx, file := range files {
+		if metas[idx] == nil {
+			missingFiles = append(missingFiles, file)
+			continue
+		}
+		meta := fileMetas[idx]
+		if meta.Error != nil {
+			log.Tracef("Could not get metadata of file %s, it will be downloaded again: %+v\n", file.FilePath, meta.Error)
+			missingFiles = append(missingFiles, file)
+			continue
+		}
+		localFile, err := getLocalFile(dir + strings.Replace(file.FilePath, "/", slash, -1))
+		if err != nil {
+			return missingFiles, fmt.Errorf("Could not read file %s: %+v", file.FilePath, err)
+		}
+		if localFile.Size != meta.FileSize {
+			log.Tracef("Local file %s has size %d, deployed file has size %d\n", file.FilePath, localFile.Size, meta.FileSize)
+			missingFiles = append(missingFiles, file)
+			continue
+		}
+		etag := strings.ToLower(strings.Trim(strings.TrimPrefix(meta.ETag, "W/"), "\""))
+		if hashETagRegexp.MatchString(etag) && etag != localFile.MD5 && etag != localFile.SHA1 && etag != localFile.SHA256 {
+			log.Tracef("Local file %s has different content (ETag: %s)\n", file.FilePath, etag)
+			missingFiles = append(missingFiles, file)
+			continue
+		}
+		log.Tracef("File %s is already downloaded\n", file.FilePath)
+	}
+
+	return missingFiles, nil
+}
+
+// getLocalFile returns size and hashes of local file
+func getLocalFile(filePath string) (archiveFile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return archiveFile{}, err
+	}
+	defer file.Close()
+	md5Hash := md5.New()
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), file)
+	if err != nil {
+		return archiveFile{}, err
+	}
+	return archiveFile{
+		Size:   int(size),
+		MD5:    hex.EncodeToString(md5Hash.Sum(nil)),
+		SHA1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+		SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+	}, nil
+}
+
+// saveAppFile writes content of application file into directory
+// by its path, starting from /<appName-appVersion>. Content is streamed
+// into temporary file, which is renamed when complete
+func saveAppFile(dir string, path string, content io.Reader) error {
+	return writeFileAtomically(dir+strings.Replace(path, "/", slash, -1), content)
+}
+
+// writeFileAtomically streams content into temporary file next to
+// given file, and renames it when complete. Missing directories are created
+func writeFileAtomically(filePath string, content io.Reader) error {
+	fileDir := filepath.Dir(filePath)
+	// Create directory
+	log.Tracef("Creating directory %s\n", fileDir)
+	err := os.MkdirAll(fileDir, 0755)
+	if err != nil {
+		return fmt.Errorf("Could not create directory %s: %+v", fileDir, err)
+	}
+	// Write temporary file
+	tmpFile, err := ioutil.TempFile(fileDir, "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Could not create temporary file in %s: %+v", fileDir, err)
+	}
+	log.Tracef("Writing file %s\n", tmpFile.Name())
+	_, err = io.Copy(tmpFile, content)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("Could not write file %s: %+v", filePath, err)
+	}
+	// Rename temporary file
+	log.Tracef("Renaming file %s to %s\n", tmpFile.Name(), filePath)
+	err = os.Rename(tmpFile.Name(), filePath)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("Could not write file %s: %+v", filePath, err)
+	}
+	return nil
+}
+
+// fetchAppFiles downloads files of applications deployed to app-host
+// concurrently and passes content stream of each file to handler. Handlers
+// are called concurrently. Failed downloads are retried with exponential
+// backoff, so handler should be able to handle the same file again. Files,
+// which could not be downloaded or handled, are returned as DownloadError
+func fetchAppFiles(html5Context HTML5Context, appHostGUID string, files []models.HTML5ApplicationFile,
+	handler func(file models.HTML5ApplicationFile, content io.Reader) error) error {
+
+	// Rate limiter for cuncurrent connections
+	rateLimiter := make(chan int, maxConcurrentConnections)
+
+	// Get and handle files
+	results := make([]chan error, len(files))
+	for idx, file := range files {
+		results[idx] = make(chan error, 1)
+		go func(file models.HTML5ApplicationFile, idx int) {
+			rateLimiter <- idx
+			defer func() { <-rateLimiter }()
+			log.Tracef("Getting content of file %s\n", file.FilePath)
+			results[idx] <- withDownloadRetry(file.FilePath, func() error {
+				content, _, err := clients.GetFileContentReader(
+					*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+					file.FilePath,
+					html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+					appHostGUID)
+				if err != nil {
+					return err
+				}
+				defer content.Close()
+				return handler(file, content)
+			})
+			log.Tracef("Recieved content of file %s\n", file.FilePath)
+		}(file, idx)
+	}
+
+	// Collect failures
+	failures := make([]DownloadFailure, 0)
+	for idx, file := range files {
+		err := <-results[idx]
+		if err != nil {
+			failures = append(failures, DownloadFailure{Path: file.FilePath, Error: err})
+		}
+	}
+	if len(failures) > 0 {
+		return &DownloadError{Failures: failures, Total: len(files)}
+	}
+
+	return nil
+}
+
+// streamAppFiles downloads files of applications deployed to app-host and
+// passes content stream of each file to handler in order of files. Requests
+// are sent concurrently, but content is read only by handler, so it is not
+// held in memory. Failed requests are retried with exponential backoff, but
+// failures while reading content are not. Files, which could not be
+// downloaded or handled, are returned as DownloadError
+func streamAppFiles(html5Context HTML5Context, appHostGUID string, files []models.HTML5ApplicationFile,
+	handler func(file models.HTML5ApplicationFile, content io.Reader, size int64) error) error {
+
+	// Content stream of file
+	type fileStream struct {
+		content io.ReadCloser
+		size    int64
+		err     error
+	}
+
+	// Rate limiter for cuncurrent connections. Connections are acquired
+	// in order of files, so handled file always has a connection
+	rateLimiter := make(chan int, maxConcurrentConnections)
+	streams := make([]chan fileStream, len(files))
+	for idx := range files {
+		streams[idx] = make(chan fileStream, 1)
+	}
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		for idx, file := range files {
+			select {
+			case rateLimiter <- idx:
+			case <-done:
+				return
+			}
+			go func(file models.HTML5ApplicationFile, idx int) {
+				var stream fileStream
+				log.Tracef("Getting content of file %s\n", file.FilePath)
+				stream.err = withDownloadRetry(file.FilePath, func() error {
+					var err error
+					stream.content, stream.size, err = clients.GetFileContentReader(
+						*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+						file.FilePath,
+						html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+						appHostGUID)
+					return err
+				})
+				streams[idx] <- stream
+			}(file, idx)
+		}
+	}()
+
+	// Handle files in order
+	failures := make([]DownloadFailure, 0)
+	for idx, file := range files {
+		stream := <-streams[idx]
+		if stream.err == nil {
+			stream.err = handler(file, stream.content, stream.size)
+			stream.content.Close()
+		}
+		<-rateLimiter
+		if stream.err != nil {
+			failures = append(failures, DownloadFailure{Path: file.FilePath, Error: stream.err})
+		}
+	}
+	if len(failures) > 0 {
+		return &DownloadError{Failures: failures, Total: len(files)}
+	}
+
+	return nil
+}
+
+// withDownloadRetry calls download function of file, retrying
+// failed calls with exponential backoff
+func withDownloadRetry(filePath string, download func() error) error {
+	var err error
+	delay := downloadRetryDelay
+	for currentTry := 1; currentTry <= maxRetryCount; currentTry++ {
+		err = download()
+		if err == nil {
+			return nil
+		}
+		log.Tracef("Could not get content of file %s (try %d/%d): %+v\n", filePath, currentTry, maxRetryCount, err)
+		if currentTry < maxRetryCount {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// testAppHost app-host with HTML5 applications served by test server
+type testAppHost struct {
+	// Content of files by path, starting from /<appName-appVersion>
+	Files map[string]string
+	// Metadata of app-host
+	Meta models.HTML5ServiceMeta
+	// Applications deployed to app-host
+	Apps models.HTML5ListApplicationsResponse
+	// Number of HEAD requests
+	HeadRequests int32
+	// Number of requests of file lists
+	ListRequests int32
+}
+
+// ServeHTTP serves metadata of app-host, lists of application files,
+// and content and metadata of files
+func (h *testAppHost) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/app-host/metadata":
+		json.NewEncoder(w).Encode(h.Meta)
+	case r.URL.Path == "/applications/metadata/":
+		json.NewEncoder(w).Encode(h.Apps)
+	case strings.HasPrefix(r.URL.Path, "/applications/files/path/"):
+		atomic.AddInt32(&h.ListRequests, 1)
+		prefix := "/" + strings.TrimPrefix(r.URL.Path, "/applications/files/path/") + "/"
+		files := make(models.HTML5ListApplicationFilesResponse, 0)
+		for path := range h.Files {
+			if strings.HasPrefix(path, prefix) {
+				files = append(files, models.HTML5ApplicationFile{FilePath: path})
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].FilePath < files[j].FilePath })
+		json.NewEncoder(w).Encode(files)
+	default:
+		content, ok := h.Files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		hash := md5.Sum([]byte(content))
+		w.Header().Set("ETag", "\""+hex.EncodeToString(hash[:])+"\"")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(&h.HeadRequests, 1)
+			return
+		}
+		w.Write([]byte(content))
+	}
+}
+
+// newTestHTML5Context returns HTML5 context with app-runtime
+// service key pointing to given URL
+func newTestHTML5Context(serviceURL string) HTML5Context {
+	return HTML5Context{
+		ServiceName: "html5-apps-repo",
+		HTML5AppRuntimeServiceInstanceKeys: []models.CFServiceKey{
+			{Credentials: models.CFCredentials{URI: &serviceURL}},
+		},
+		HTML5AppRuntimeServiceInstanceKeyToken: "token",
+	}
+}
+
+func TestGetMissingAppFiles(t *testing.T) {
+	appHost := &testAppHost{Files: make(map[string]string)}
+	local := make(map[string]string)
+	expected := make([]string, 0)
+	// More files than concurrent connections
+	for i := 0; i < 2*maxConcurrentConnections; i++ {
+		path := fmt.Sprintf("/app-1.0.0/file%03d.txt", i)
+		appHost.Files[path] = fmt.Sprintf("content %03d", i)
+		switch i % 4 {
+		case 0:
+			// Same file
+			local[path] = appHost.Files[path]
+		case 1:
+			// Different size
+			local[path] = appHost.Files[path] + "!"
+			expected = append(expected, path)
+		case 2:
+			// Different content of the same size
+			local[path] = fmt.Sprintf("CONTENT %03d", i)
+			expected = append(expected, path)
+		case 3:
+			// Missing file
+			expected = append(expected, path)
+		}
+	}
+	// Directory instead of file
+	appHost.Files["/app-1.0.0/dir"] = "content"
+	expected = append(expected, "/app-1.0.0/dir")
+	sort.Strings(expected)
+
+	server := httptest.NewServer(appHost)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "html5-download-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for path, content := range local {
+		if err = saveAppFile(dir, path, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.MkdirAll(filepath.Join(dir, "app-1.0.0", "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := make([]models.HTML5ApplicationFile, 0)
+	for path := range appHost.Files {
+		files = append(files, models.HTML5ApplicationFile{FilePath: path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].FilePath < files[j].FilePath })
+
+	missingFiles, err := getMissingAppFiles(newTestHTML5Context(server.URL), "app-host-1", files, dir)
+	if err != nil {
+		t.Fatalf("getMissingAppFiles returned error: %s", err.Error())
+	}
+	missing := make([]string, 0)
+	for _, file := range missingFiles {
+		missing = append(missing, file.FilePath)
+	}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("getMissingAppFiles returned %v, expected %v", missing, expected)
+	}
+	if requests := atomic.LoadInt32(&appHost.HeadRequests); int(requests) != len(local) {
+		t.Errorf("Metadata of %d files requested, expected %d files existing locally", requests, len(local))
+	}
+	for _, file := range files {
+		if file.FileMetadata != (models.HTML5ApplicationFileMetadata{}) {
+			t.Errorf("getMissingAppFiles changed metadata of file %s: %+v", file.FilePath, file.FileMetadata)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Extensions of application archives by archive format
+var archiveExtensions = map[string]string{
+	"zip": ".zip",
+	"tgz": ".tar.gz",
+}
+
+// Directory with application archives in html5-app-deployer layout
+const deployerResourcesDir = "resources"
+
+// appArchiveWriter writes files of application into archive
+type appArchiveWriter interface {
+	// WriteFile adds file with path relative to application root.
+	// Size of content can be -1, if unknown
+	WriteFile(name string, size int64, content io.Reader) error
+	// Close completes archive. Underlying writer is not closed
+	Close() error
+}
+
+// zipAppArchiveWriter writes zip archive
+type zipAppArchiveWriter struct {
+	archive *zip.Writer
+}
+
+// WriteFile adds file to zip archive
+func (w *zipAppArchiveWriter) WriteFile(name string, size int64, content io.Reader) error {
+	writer, err := w.archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, content)
+	return err
+}
+
+// Close completes zip archive
+func (w *zipAppArchiveWriter) Close() error {
+	return w.archive.Close()
+}
+
+// tgzAppArchiveWriter writes gzip-compressed tar archive
+type tgzAppArchiveWriter struct {
+	compressor *gzip.Writer
+	archive    *tar.Writer
+}
+
+// WriteFile adds file to tar archive. Tar header requires size of file,
+// so content of unknown size is read into memory first
+func (w *tgzAppArchiveWriter) WriteFile(name string, size int64, content io.Reader) error {
+	if size < 0 {
+		buffer, err := ioutil.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		size = int64(len(buffer))
+		content = bytes.NewReader(buffer)
+	}
+	err := w.archive.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w.archive, content)
+	return err
+}
+
+// Close completes tar archive and gzip stream
+func (w *tgzAppArchiveWriter) Close() error {
+	err := w.archive.Close()
+	if err != nil {
+		return err
+	}
+	return w.compressor.Close()
+}
+
+// newAppArchiveWriter returns writer of application archive in given format
+func newAppArchiveWriter(format string, writer io.Writer) (appArchiveWriter, error) {
+	switch format {
+	case "zip":
+		return &zipAppArchiveWriter{archive: zip.NewWriter(writer)}, nil
+	case "tgz":
+		compressor := gzip.NewWriter(writer)
+		return &tgzAppArchiveWriter{compressor: compressor, archive: tar.NewWriter(compressor)}, nil
+	}
+	return nil, fmt.Errorf("Archive format '%s' is not supported. Supported formats: zip, tgz", format)
+}
+
+// archiveAppFiles downloads files of application deployed to app-host and
+// streams each of them into archive. Paths in archive are relative to
+// application root
+func archiveAppFiles(html5Context HTML5Context, appHostGUID string, appKey string,
+	files []models.HTML5ApplicationFile, archive appArchiveWriter) error {
+	return streamAppFiles(html5Context, appHostGUID, files, func(file models.HTML5ApplicationFile, content io.Reader, size int64) error {
+		name := strings.TrimPrefix(strings.TrimPrefix(file.FilePath, "/"), appKey+"/")
+		log.Tracef("Adding file %s to archive of application %s\n", name, appKey)
+		err := archive.WriteFile(name, size, content)
+		if err != nil {
+			return fmt.Errorf("Could not add file %s to archive: %+v", file.FilePath, err)
+		}
+		return nil
+	})
+}
+
+// writeAppArchive downloads files of application deployed to app-host
+// into archive file. Incomplete archive file is deleted
+func writeAppArchive(html5Context HTML5Context, appHostGUID string, appKey string,
+	files []models.HTML5ApplicationFile, format string, fileName string) error {
+	log.Tracef("Writing archive of application %s to %s\n", appKey, fileName)
+	err := os.MkdirAll(filepath.Dir(fileName), 0755)
+	if err != nil {
+		return fmt.Errorf("Could not create directory %s: %+v", filepath.Dir(fileName), err)
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("Could not create archive %s: %+v", fileName, err)
+	}
+	err = streamAppArchive(html5Context, appHostGUID, appKey, files, format, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("Could not write archive %s: %+v", fileName, closeErr)
+	}
+	if err != nil {
+		os.Remove(fileName)
+		return err
+	}
+	return nil
+}
+
+// streamAppArchive downloads files of application deployed to app-host
+// into archive written to writer
+func streamAppArchive(html5Context HTML5Context, appHostGUID string, appKey string,
+	files []models.HTML5ApplicationFile, format string, writer io.Writer) error {
+	archive, err := newAppArchiveWriter(format, writer)
+	if err != nil {
+		return err
+	}
+	err = archiveAppFiles(html5Context, appHostGUID, appKey, files, archive)
+	if err != nil {
+		return err
+	}
+	err = archive.Close()
+	if err != nil {
+		return fmt.Errorf("Could not complete archive of application %s: %+v", appKey, err)
+	}
+	return nil
+}
@@ -5,8 +5,11 @@ import (
 	"cf-html5-apps-repo-cli-plugin/clients/models"
 	"cf-html5-apps-repo-cli-plugin/log"
 	"cf-html5-apps-repo-cli-plugin/ui"
-	"io/ioutil"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry/cli/cf/terminal"
@@ -25,15 +28,21 @@ func (c *GetCommand) GetPluginCommand() plugin.Command {
 		Name:     "html5-get",
 		HelpText: "Fetch content of single HTML5 application file by path, or whole application by name and version",
 		UsageDetails: plugin.Usage{
-			Usage: "cf html5-get PATH|APPKEY|--all [APP_HOST_ID|-n APP_HOST_NAME] [--out OUTPUT]",
+			Usage: "cf html5-get PATH|APPKEY|SERVICE.APPKEY|--all [APP_HOST_ID|-n APP_HOST_NAME|-d|-di DESTINATION_SERVICE_INSTANCE_NAME|-a CF_APP_NAME] [--out OUTPUT] [--archive zip|tgz] [--resume]",
 			Options: map[string]string{
-				"PATH":          "Application file path, starting from /<appName-appVersion>",
-				"APPKEY":        "Application name and version",
-				"APP_HOST_ID":   "GUID of html5-apps-repo app-host service instance that contains application with specified name and version",
-				"APP_HOST_NAME": "Name of html5-apps-repo app-host service instance that contains application with specified name and version",
-				"-all":          "Flag that indicates that all applications of specified APP_HOST_ID or APP_HOST_NAME should be fetched",
-				"-name, -n":     "Use html5-apps-repo app-host service instance name instead of APP_HOST_ID",
-				"-out, -o":      "Output file (for single file) or output directory (for application). By default, standard output and current working directory",
+				"PATH":                       "Application file path, starting from /<appName-appVersion>",
+				"APPKEY":                     "Application name and version",
+				"APP_HOST_ID":                "GUID of html5-apps-repo app-host service instance that contains application with specified name and version",
+				"APP_HOST_NAME":              "Name of html5-apps-repo app-host service instance that contains application with specified name and version",
+				"SERVICE":                    "Business service prefix of application: sap.cloud.service without dots, or sap.cloud.service.alias of service binding. GUID of destination service instance shown in URLs of 'cf html5-list -u -di' can be omitted",
+				"-all":                       "Flag that indicates that all applications of specified APP_HOST_ID or APP_HOST_NAME should be fetched",
+				"-name, -n":                  "Use html5-apps-repo app-host service instance name instead of APP_HOST_ID",
+				"-destination, -d":           "Fetch HTML5 application exposed via subaccount destinations with sap.cloud.service and html5-apps-repo.app_host_id properties",
+				"-destination-instance, -di": "Fetch HTML5 application exposed via service instance destinations with sap.cloud.service and html5-apps-repo.app_host_id properties ('*' for all destination service instances)",
+				"-app, -a":                   "Fetch HTML5 application of business service bound to CF application",
+				"-out, -o":                   "Output file (for single file) or output directory (for application). By default, standard output and current working directory. With --archive option, output archive file of application ('-' for standard output), or output directory for archives of all applications",
+				"-resume":                    "Skip application files already downloaded into output directory by previous interrupted run, which size and ETag match deployed files",
+				"-archive":                   "Download application as zip or tgz archive instead of directory tree. With --all flag, one archive per application is written to resources folder of output directory (html5-app-deployer layout)",
 			},
 		},
 	}
@@ -46,8 +55,18 @@ func (c *GetCommand) Execute(args []string) ExecutionStatus {
 	// Parse arguments
 	var key = "_"
 	var argsMap = make(map[string][]string)
+	var resume = false
+	var destination = false
 	for _, arg := range args {
-		if string(arg[0]) == "-" {
+		if arg == "--resume" {
+			resume = true
+			continue
+		}
+		if arg == "-d" || arg == "--destination" {
+			destination = true
+			continue
+		}
+		if string(arg[0]) == "-" && arg != "-" {
 			key = arg
 			continue
 		}
@@ -96,14 +115,91 @@ func (c *GetCommand) Execute(args []string) ExecutionStatus {
 		output = argsMap["--out"][0]
 	}
 
+	// Archive format
+	var archive = ""
+	if argsMap["--archive"] != nil {
+		if len(argsMap["--archive"]) != 1 {
+			ui.Failed("Incorrect number of arguments for --archive option (expected: 1, actual: %d). For help see [cf html5-get --help]", len(argsMap["--archive"]))
+			return Failure
+		}
+		archive = argsMap["--archive"][0]
+		if _, ok := archiveExtensions[archive]; !ok {
+			ui.Failed("Archive format '%s' is not supported. Supported formats: zip, tgz", archive)
+			return Failure
+		}
+	}
+	if output == "-" && archive == "" {
+		ui.Failed("Standard output ('-') can only be used as OUTPUT together with --archive option")
+		return Failure
+	}
+	if resume && archive != "" {
+		ui.Failed("Options --resume and --archive can't be used at the same time")
+		return Failure
+	}
+
+	// Destination (instance)
+	var destinationInstance = ""
+	if argsMap["-di"] != nil && argsMap["--destination-instance"] != nil {
+		ui.Failed("Can't use both '--destination-instance' and '-di' at the same time")
+		return Failure
+	}
+	if argsMap["-di"] != nil {
+		argsMap["--destination-instance"] = argsMap["-di"]
+	}
+	if argsMap["--destination-instance"] != nil {
+		if len(argsMap["--destination-instance"]) != 1 {
+			ui.Failed("Incorrect number of arguments for DESTINATION_SERVICE_INSTANCE_NAME option (expected: 1, actual: %d). For help see [cf html5-get --help]", len(argsMap["--destination-instance"]))
+			return Failure
+		}
+		destinationInstance = argsMap["--destination-instance"][0]
+	}
+
+	// App
+	var app = ""
+	if argsMap["-a"] != nil && argsMap["--app"] != nil {
+		ui.Failed("Can't use both '--app' and '-a' at the same time")
+		return Failure
+	}
+	if argsMap["-a"] != nil {
+		argsMap["--app"] = argsMap["-a"]
+	}
+	if argsMap["--app"] != nil {
+		if len(argsMap["--app"]) != 1 {
+			ui.Failed("Incorrect number of arguments for CF_APP_NAME option (expected: 1, actual: %d). For help see [cf html5-get --help]", len(argsMap["--app"]))
+			return Failure
+		}
+		app = argsMap["--app"][0]
+	}
+
+	// Get application of business service
+	if destination || destinationInstance != "" || app != "" {
+		if (destination && destinationInstance != "") || (app != "" && (destination || destinationInstance != "")) {
+			ui.Failed("Only one of '--destination', '--destination-instance' and '--app' options can be used at the same time")
+			return Failure
+		}
+		if name != "" || argsMap["--all"] != nil {
+			ui.Failed("Options '--destination', '--destination-instance' and '--app' can't be used together with APP_HOST_ID, APP_HOST_NAME or '--all'")
+			return Failure
+		}
+		if len(argsMap["_"]) != 1 {
+			ui.Failed("Incorrect number of arguments passed. See [cf html5-get --help] for more details")
+			return Failure
+		}
+		if strings.Index(argsMap["_"][0], "/") >= 0 {
+			ui.Failed("Only whole applications can be fetched with '--destination', '--destination-instance' and '--app' options")
+			return Failure
+		}
+		return c.GetServiceApplicationFilesContents(output, argsMap["_"][0], destination, destinationInstance, app, archive, resume)
+	}
+
 	// Get all apps in app-host by name
 	if len(argsMap["--all"]) == 0 && len(argsMap["_"]) == 0 && name != "" {
-		return c.GetAppHostFilesContents(output, name, true)
+		return c.GetAppHostFilesContents(output, name, true, archive, resume)
 	}
 
 	// Get all apps in app-host-id
 	if len(argsMap["--all"]) == 1 {
-		return c.GetAppHostFilesContents(output, argsMap["--all"][0], false)
+		return c.GetAppHostFilesContents(output, argsMap["--all"][0], false, archive, resume)
 	}
 
 	// Define app-host Name or GUID
@@ -121,9 +217,13 @@ func (c *GetCommand) Execute(args []string) ExecutionStatus {
 			if len(appKeyParts) == 1 {
 				appKeyParts = append(appKeyParts, "")
 			}
-			return c.GetApplicationFilesContents(output, appKeyParts[0], appKeyParts[1], appHostNameOrGUID, name != "")
+			return c.GetApplicationFilesContents(output, appKeyParts[0], appKeyParts[1], appHostNameOrGUID, name != "", archive, resume)
 		}
 		// Get single file
+		if archive != "" || resume {
+			ui.Failed("Options --archive and --resume can only be used to fetch whole applications")
+			return Failure
+		}
 		return c.GetFileContents(output, argsMap["_"][0], appHostNameOrGUID, name != "")
 	}
 
@@ -131,12 +231,16 @@ func (c *GetCommand) Execute(args []string) ExecutionStatus {
 	return Failure
 }
 
-// GetAppHostFilesContents get files contents of all applications of app-host-id
-func (c *GetCommand) GetAppHostFilesContents(output string, appHostNameOrGUID string, isName bool) ExecutionStatus {
+// GetAppHostFilesContents get files contents of all applications of app-host-id.
+// If archive format is specified, each application is written into separate archive.
+// If resume is true, files already downloaded into output directory are skipped
+func (c *GetCommand) GetAppHostFilesContents(output string, appHostNameOrGUID string, isName bool, archive string, resume bool) ExecutionStatus {
 	log.Tracef("Get content of files of applications of app-host: '%s'\n", appHostNameOrGUID)
 
-	// Channel to control number of concurrent connections
-	rateLimiter := make(chan int, maxConcurrentConnections)
+	if output == "-" {
+		ui.Failed("Archives of all applications can not be written to standard output. Use output directory instead")
+		return Failure
+	}
 
 	// Get context
 	log.Tracef("Getting context (org/space/username)\n")
@@ -201,6 +305,8 @@ func (c *GetCommand) GetAppHostFilesContents(output string, appHostNameOrGUID st
 	}
 
 	var allFiles = make([]models.HTML5ApplicationFile, 0)
+	var archiveTable = ui.Table([]string{"application", "archive", "files"})
+	var archiveErrors = &DownloadError{Failures: make([]DownloadFailure, 0)}
 	for _, application := range applications {
 		var appKey = application.ApplicationName + "-" + application.ApplicationVersion
 		// Get list of files for app-host-id and app key
@@ -216,54 +322,38 @@ func (c *GetCommand) GetAppHostFilesContents(output string, appHostNameOrGUID st
 		}
 		log.Tracef("Number of files for application '%s': %d\n", appKey, len(files))
 		allFiles = append(allFiles, files...)
-	}
 
-	// Get files
-	filesChannels := make([]chan models.HTML5ApplicationFileContent, len(allFiles))
-	for idx, file := range allFiles {
-		log.Tracef("Getting content of file %s\n", file.FilePath)
-		filesChannel := make(chan models.HTML5ApplicationFileContent, 1)
-		filesChannels[idx] = filesChannel
-		go func(file models.HTML5ApplicationFile, idx int) {
-			rateLimiter <- idx
-			clients.GetFileContent(
-				*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
-				file.FilePath,
-				html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
-				appHostGUID,
-				filesChannel)
-			log.Tracef("Recieved content of file %s\n", file.FilePath)
-		}(file, idx)
-	}
-
-	// Save files
-	for i := 0; i < len(allFiles); i++ {
-		var idx int = <-rateLimiter
-		file := allFiles[idx]
-		fileContent := <-filesChannels[idx]
-		if fileContent.Error != nil {
-			ui.Failed("Could not get file contents of %s: %+v", file.FilePath, err)
-			return Failure
+		// Get and archive files of application
+		if archive != "" {
+			fileName := cwd + slash + deployerResourcesDir + slash + appKey + archiveExtensions[archive]
+			err = writeAppArchive(html5Context, appHostGUID, appKey, files, archive, fileName)
+			if downloadErr, ok := err.(*DownloadError); ok {
+				archiveErrors.Failures = append(archiveErrors.Failures, downloadErr.Failures...)
+				archiveErrors.Total += len(files)
+				continue
+			}
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
+			archiveErrors.Total += len(files)
+			archiveTable.Add(appKey, fileName, strconv.Itoa(len(files)))
 		}
-		// File path
-		filePath := cwd + strings.Replace(file.FilePath, "/", slash, -1)
-		// Directory path
-		dirPath := strings.Split(filePath, slash)
-		dirPath = dirPath[:len(dirPath)-1]
-		dir := strings.Join(dirPath, slash)
-		// Create directory
-		log.Tracef("Creating directory %s\n", dir)
-		err = os.MkdirAll(dir, 0755)
+	}
+	if len(archiveErrors.Failures) > 0 {
+		printDownloadError(archiveErrors)
+		return Failure
+	}
+
+	// Get and save files
+	if archive == "" {
+		skipped, err := downloadAppFiles(html5Context, appHostGUID, allFiles, cwd, resume)
 		if err != nil {
-			ui.Failed("Could not create directory %s: %+v", dir, err)
+			printDownloadError(err)
 			return Failure
 		}
-		// Write file
-		log.Tracef("Writing file %s\n", filePath)
-		err = ioutil.WriteFile(filePath, fileContent.Content, 0644)
-		if err != nil {
-			ui.Failed("Could not write file %s: %+v", filePath, err)
-			return Failure
+		if resume {
+			ui.Say("Skipped %d of %d files already downloaded", skipped, len(allFiles))
 		}
 	}
 
@@ -277,6 +367,12 @@ func (c *GetCommand) GetAppHostFilesContents(output string, appHostNameOrGUID st
 	ui.Ok()
 	ui.Say("")
 
+	// Display information about application archives
+	if archive != "" {
+		archiveTable.Print()
+		return Success
+	}
+
 	// Display information about HTML5 application files
 	table := ui.Table([]string{"path"})
 	for _, file := range allFiles {
@@ -326,62 +422,60 @@ func (c *GetCommand) GetFileContents(output string, filePath string, appHostName
 	}
 
 	// Get file contents
-	fileContentChan := make(chan models.HTML5ApplicationFileContent)
-	go clients.GetFileContent(
+	content, _, err := clients.GetFileContentReader(
 		*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
 		filePath,
 		html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
-		appHostGUID,
-		fileContentChan)
-	fileContent := <-fileContentChan
-
-	if fileContent.Error != nil {
-		ui.Failed("Could not get file contents of %s: %+v", filePath, err)
-		return Failure
-	}
-
-	// Clean-up HTML5 context
-	err = c.CleanHTML5Context(html5Context)
+		appHostGUID)
 	if err != nil {
-		ui.Failed(err.Error())
+		ui.Failed("Could not get file contents of %s: %+v", filePath, err)
 		return Failure
 	}
+	defer content.Close()
 
 	if output == "" {
 		// Print to stdout
 		ui.Ok()
 		ui.Say("")
-		ui.Say(string(fileContent.Content))
-	} else {
-		// Directory path
-		dirPath := strings.Split(output, slash)
-		dirPath = dirPath[:len(dirPath)-1]
-		dir := strings.Join(dirPath, slash)
-		// Create directory
-		log.Tracef("Creating directory %s\n", dir)
-		err = os.MkdirAll(dir, 0755)
+		_, err = io.Copy(ui.Stdout(), content)
 		if err != nil {
-			ui.Failed("Could not create directory %s: %+v", dir, err)
+			ui.Failed("Could not get file contents of %s: %+v", filePath, err)
 			return Failure
 		}
+		ui.Say("")
+	} else {
 		// Write file
 		log.Tracef("Writing file %s\n", output)
-		err = ioutil.WriteFile(output, fileContent.Content, 0644)
+		err = writeFileAtomically(output, content)
 		if err != nil {
-			ui.Failed("Could not write file %s: %+v", output, err)
+			ui.Failed(err.Error())
 			return Failure
 		}
 		ui.Ok()
 		ui.Say("")
 	}
 
+	// Clean-up HTML5 context
+	err = c.CleanHTML5Context(html5Context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
 	return Success
 }
 
-// GetApplicationFilesContents get application files contents
-func (c *GetCommand) GetApplicationFilesContents(output string, appName string, appVersion string, appHostNameOrGUID string, isName bool) ExecutionStatus {
+// GetApplicationFilesContents get application files contents.
+// If archive format is specified, application is written into archive.
+// If resume is true, files already downloaded into output directory are skipped
+func (c *GetCommand) GetApplicationFilesContents(output string, appName string, appVersion string, appHostNameOrGUID string, isName bool, archive string, resume bool) ExecutionStatus {
 	log.Tracef("Getting content of application with name: '%s' version: '%s'\n", appName, appVersion)
 
+	// Archive is written to standard output, print messages to standard error
+	if output == "-" {
+		defer ui.RedirectToStderr()()
+	}
+
 	// Calculate application key
 	var appKey = appName
 	if appVersion != "" {
@@ -440,6 +534,12 @@ func (c *GetCommand) GetApplicationFilesContents(output string, appName string,
 		appHostGUID = serviceInstance.GUID
 	}
 
+	return c.getApplicationFiles(html5Context, output, appKey, appHostGUID, archive, resume)
+}
+
+// getApplicationFiles downloads files of application with given key from
+// app-host into output directory or archive, and cleans HTML5 context
+func (c *GetCommand) getApplicationFiles(html5Context HTML5Context, output string, appKey string, appHostGUID string, archive string, resume bool) ExecutionStatus {
 	// Get list of files
 	files, err := clients.ListFilesOfApp(
 		*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
@@ -451,6 +551,39 @@ func (c *GetCommand) GetApplicationFilesContents(output string, appName string,
 		return Failure
 	}
 
+	// Get files and write them into archive
+	if archive != "" {
+		var fileName = "standard output"
+		if output == "-" {
+			err = streamAppArchive(html5Context, appHostGUID, appKey, files, archive, ui.Stdout())
+		} else {
+			fileName = output
+			if fileName == "" {
+				fileName = appKey + archiveExtensions[archive]
+			} else if info, statErr := os.Stat(fileName); statErr == nil && info.IsDir() {
+				fileName = filepath.Join(fileName, appKey+archiveExtensions[archive])
+			}
+			err = writeAppArchive(html5Context, appHostGUID, appKey, files, archive, fileName)
+		}
+		if err != nil {
+			printDownloadError(err)
+			return Failure
+		}
+
+		// Clean-up HTML5 context
+		err = c.CleanHTML5Context(html5Context)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+
+		ui.Ok()
+		ui.Say("")
+		ui.Say("Archive of %d files of application %s written to %s", len(files),
+			terminal.EntityNameColor(appKey), terminal.EntityNameColor(fileName))
+		return Success
+	}
+
 	var cwd string
 	if output == "" {
 		// Get current working directory
@@ -469,55 +602,14 @@ func (c *GetCommand) GetApplicationFilesContents(output string, appName string,
 		cwd = string(cwd[:len(cwd)-1])
 	}
 
-	// Rate limiter for cuncurrent connections
-	rateLimiter := make(chan int, maxConcurrentConnections)
-
-	// Get files
-	filesChannels := make([]chan models.HTML5ApplicationFileContent, len(files))
-	for idx, file := range files {
-		log.Tracef("Getting content of file %s\n", file.FilePath)
-		filesChannel := make(chan models.HTML5ApplicationFileContent)
-		filesChannels[idx] = filesChannel
-		go func(file models.HTML5ApplicationFile, idx int) {
-			rateLimiter <- idx
-			clients.GetFileContent(
-				*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
-				file.FilePath,
-				html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
-				appHostGUID,
-				filesChannel)
-		}(file, idx)
-	}
-
-	// Save files
-	for i := 0; i < len(files); i++ {
-		var idx int = <-rateLimiter
-		file := files[idx]
-		fileContent := <-filesChannels[idx]
-		if fileContent.Error != nil {
-			ui.Failed("Could not get file contents of %s: %+v", file.FilePath, err)
-			return Failure
-		}
-		// File path
-		filePath := cwd + strings.Replace(file.FilePath, "/", slash, -1)
-		// Directory path
-		dirPath := strings.Split(filePath, slash)
-		dirPath = dirPath[:len(dirPath)-1]
-		dir := strings.Join(dirPath, slash)
-		// Create directory
-		log.Tracef("Creating directory %s\n", dir)
-		err = os.MkdirAll(dir, 0755)
-		if err != nil {
-			ui.Failed("Could not create directory %s: %+v", dir, err)
-			return Failure
-		}
-		// Write file
-		log.Tracef("Writing file %s\n", filePath)
-		err = ioutil.WriteFile(filePath, fileContent.Content, 0644)
-		if err != nil {
-			ui.Failed("Could not write file %s: %+v", filePath, err)
-			return Failure
-		}
+	// Get and save files
+	skipped, err := downloadAppFiles(html5Context, appHostGUID, files, cwd, resume)
+	if err != nil {
+		printDownloadError(err)
+		return Failure
+	}
+	if resume {
+		ui.Say("Skipped %d of %d files already downloaded", skipped, len(files))
 	}
 
 	// Clean-up HTML5 context
@@ -539,3 +631,123 @@ func (c *GetCommand) GetApplicationFilesContents(output string, appName string,
 
 	return Success
 }
+
+// GetServiceApplicationFilesContents get files contents of application of business
+// service exposed via destinations, or bound to CF application. Application key is
+// prefixed by business service (SERVICE.APP-VERSION), as in application URLs
+func (c *GetCommand) GetServiceApplicationFilesContents(output string, serviceAppKey string, destination bool,
+	destinationInstance string, appName string, archive string, resume bool) ExecutionStatus {
+	log.Tracef("Resolving app-host of application '%s' of business service\n", serviceAppKey)
+
+	// Archive is written to standard output, print messages to standard error
+	if output == "-" {
+		defer ui.RedirectToStderr()()
+	}
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	if appName != "" {
+		ui.Say("Resolving app-host of HTML5 application %s available in scope of application %s in org %s / space %s as %s...",
+			terminal.EntityNameColor(serviceAppKey),
+			terminal.EntityNameColor(appName),
+			terminal.EntityNameColor(context.Org),
+			terminal.EntityNameColor(context.Space),
+			terminal.EntityNameColor(context.Username))
+	} else {
+		ui.Say("Resolving app-host of HTML5 application %s available via destinations in org %s / space %s as %s...",
+			terminal.EntityNameColor(serviceAppKey),
+			terminal.EntityNameColor(context.Org),
+			terminal.EntityNameColor(context.Space),
+			terminal.EntityNameColor(context.Username))
+	}
+
+	// Get HTML5 context
+	html5Context, err := c.GetHTML5Context(context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Application URLs of service instance destinations are prefixed
+	// by GUID of destination service instance, which is not needed
+	if destinationInstance != "" {
+		if parts := strings.SplitN(serviceAppKey, ".", 2); len(parts) == 2 {
+			if match, _ := regexp.MatchString("^[A-Za-z0-9]{8}-([A-Za-z0-9]{4}-){3}[A-Za-z0-9]{12}$", parts[0]); match {
+				log.Tracef("Ignoring destination service instance GUID prefix '%s'\n", parts[0])
+				serviceAppKey = parts[1]
+			}
+		}
+	}
+
+	var appHosts []ServiceAppHost
+	if appName != "" {
+		// Get Cloud Foundry application details
+		app, err := clients.GetApplication(c.CliConnection, context.SpaceID, appName)
+		if err != nil {
+			ui.Failed("Could not get application metadata: %s", err.Error())
+			return Failure
+		}
+
+		// Get Cloud Foundry application environment
+		env, err := clients.GetEnvironment(c.CliConnection, app.GUID)
+		if err != nil {
+			ui.Failed("Could not get application environment: %s", err.Error())
+			return Failure
+		}
+		appHosts = getBoundAppHosts(env)
+	} else {
+		// Get destinations
+		destinations, destinationContext, err := c.getDestinations(context, destinationInstance)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		appHosts = getDestinationAppHosts(destinations)
+
+		// Clean-up destination context
+		err = c.CleanDestinationContext(destinationContext)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+	}
+	log.Tracef("App-hosts of business services: %+v\n", appHosts)
+
+	// Find application
+	appHost, application, err := findServiceApp(html5Context, appHosts, serviceAppKey)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	appKey := application.ApplicationName + "-" + application.ApplicationVersion
+	ui.Say("Application %s of service %s is deployed to app-host %s",
+		terminal.EntityNameColor(appKey),
+		terminal.EntityNameColor(appHost.ServiceName),
+		terminal.EntityNameColor(appHost.AppHostGUID))
+
+	return c.getApplicationFiles(html5Context, output, appKey, appHost.AppHostGUID, archive, resume)
+}
+
+// printDownloadError prints table of files, which could not be downloaded,
+// or error message, if error is not a download error
+func printDownloadError(err error) {
+	downloadErr, ok := err.(*DownloadError)
+	if !ok {
+		ui.Failed(err.Error())
+		return
+	}
+	table := ui.Table([]string{"path", "error"})
+	for _, failure := range downloadErr.Failures {
+		table.Add(failure.Path, failure.Error.Error())
+	}
+	table.Print()
+	ui.Say("")
+	ui.Failed("Could not download %d of %d files", len(downloadErr.Failures), downloadErr.Total)
+}
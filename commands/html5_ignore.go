@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bufio"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Names of ignore files in order of precedence
+var ignoreFileNames = []string{".html5ignore", ".cfignore"}
+
+// ignorePattern single pattern of ignore file
+type ignorePattern struct {
+	regexp  *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher matches paths relative to application directory
+// against patterns of ignore file (gitignore syntax)
+type ignoreMatcher struct {
+	// Name of ignore file
+	FileName string
+	patterns []ignorePattern
+	dirs     map[string]bool
+}
+
+// loadIgnoreFile reads .html5ignore (or .cfignore if not found) file
+// in application directory. Returns nil if there is no ignore file
+func loadIgnoreFile(appPath string) (*ignoreMatcher, error) {
+	for _, fileName := range ignoreFileNames {
+		ignoreFilePath := filepath.Join(appPath, fileName)
+		file, err := os.Open(ignoreFilePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		log.Tracef("Reading ignore file: '%s'\n", ignoreFilePath)
+		matcher := &ignoreMatcher{FileName: fileName, patterns: make([]ignorePattern, 0), dirs: make(map[string]bool)}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			pattern, ok := parseIgnorePattern(scanner.Text())
+			if ok {
+				matcher.patterns = append(matcher.patterns, pattern)
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+		return matcher, nil
+	}
+	return nil, nil
+}
+
+// parseIgnorePattern converts line of ignore file to pattern
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	var pattern ignorePattern
+
+	// Skip empty lines and comments
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern, false
+	}
+	if strings.HasPrefix(line, "\\#") || strings.HasPrefix(line, "\\!") {
+		line = line[1:]
+	} else if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern, false
+	}
+
+	// Patterns with slash are relative to application directory,
+	// other patterns match on any level
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	expression := "^"
+	if !anchored {
+		expression += "(.*/)?"
+	}
+	for i := 0; i < len(line); i++ {
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			expression += "(.*/)?"
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			expression += "/.*"
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			expression += ".*"
+			i++
+		case line[i] == '*':
+			expression += "[^/]*"
+		case line[i] == '?':
+			expression += "[^/]"
+		case line[i] == '[':
+			end := strings.Index(line[i:], "]")
+			if end > 1 {
+				class := line[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				expression += "[" + strings.Replace(class, "\\", "\\\\", -1) + "]"
+				i += end
+			} else {
+				expression += regexp.QuoteMeta(line[i : i+1])
+			}
+		case line[i] == '\\' && i+1 < len(line):
+			expression += regexp.QuoteMeta(line[i+1 : i+2])
+			i++
+		default:
+			expression += regexp.QuoteMeta(line[i : i+1])
+		}
+	}
+	expression += "$"
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		log.Tracef("Skipping invalid ignore pattern '%s': %+v\n", line, err)
+		return pattern, false
+	}
+	pattern.regexp = re
+
+	return pattern, true
+}
+
+// Ignored checks if file or directory with given path relative
+// to application directory (slash separated) should be ignored
+func (m *ignoreMatcher) Ignored(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	// Files of ignored directories can't be included again
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.ignoredDir(strings.Join(parts[:i], "/")) {
+			return true
+		}
+	}
+	if isDir {
+		return m.ignoredDir(relPath)
+	}
+
+	return m.match(relPath, false)
+}
+
+// ignoredDir checks if directory should be ignored, caching the result
+func (m *ignoreMatcher) ignoredDir(relPath string) bool {
+	ignored, ok := m.dirs[relPath]
+	if !ok {
+		ignored = m.match(relPath, true)
+		m.dirs[relPath] = ignored
+	}
+	return ignored
+}
+
+// match applies all patterns to path, last matching pattern wins
+func (m *ignoreMatcher) match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range m.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if pattern.regexp.MatchString(relPath) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnorePattern(t *testing.T) {
+	tests := []struct {
+		line       string
+		ok         bool
+		negate     bool
+		dirOnly    bool
+		matches    []string
+		mismatches []string
+	}{
+		{line: "", ok: false},
+		{line: "   ", ok: false},
+		{line: "# comment", ok: false},
+		{line: "!", ok: false},
+		{line: "/", ok: false},
+		{line: "*.log", ok: true, matches: []string{"a.log", "dir/a.log", "dir/sub/.log"}, mismatches: []string{"a.log.txt", "log"}},
+		{line: "*.log \r", ok: true, matches: []string{"a.log"}},
+		{line: "!keep.log", ok: true, negate: true, matches: []string{"keep.log", "dir/keep.log"}},
+		{line: "\\!important", ok: true, matches: []string{"!important"}, mismatches: []string{"important"}},
+		{line: "\\#hash", ok: true, matches: []string{"#hash"}},
+		{line: "node_modules/", ok: true, dirOnly: true, matches: []string{"node_modules", "a/node_modules"}},
+		{line: "/dist", ok: true, matches: []string{"dist"}, mismatches: []string{"a/dist"}},
+		{line: "docs/*.md", ok: true, matches: []string{"docs/a.md"}, mismatches: []string{"a/docs/a.md", "docs/a/b.md"}},
+		{line: "**/test", ok: true, matches: []string{"test", "a/test", "a/b/test"}, mismatches: []string{"atest"}},
+		{line: "build/**", ok: true, matches: []string{"build/a", "build/a/b"}, mismatches: []string{"build"}},
+		{line: "a/**/b", ok: true, matches: []string{"a/b", "a/x/b", "a/x/y/b"}, mismatches: []string{"a/xb"}},
+		{line: "file?.txt", ok: true, matches: []string{"file1.txt"}, mismatches: []string{"file.txt", "file12.txt"}},
+		{line: "file[0-9].txt", ok: true, matches: []string{"file1.txt"}, mismatches: []string{"filea.txt"}},
+		{line: "file[!0-9].txt", ok: true, matches: []string{"filea.txt"}, mismatches: []string{"file1.txt"}},
+		{line: "a[b", ok: true, matches: []string{"a[b"}},
+		{line: "a.b", ok: true, matches: []string{"a.b"}, mismatches: []string{"axb"}},
+	}
+	for _, test := range tests {
+		pattern, ok := parseIgnorePattern(test.line)
+		if ok != test.ok {
+			t.Errorf("parseIgnorePattern(%q) ok = %v, expected %v", test.line, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if pattern.negate != test.negate || pattern.dirOnly != test.dirOnly {
+			t.Errorf("parseIgnorePattern(%q) negate = %v, dirOnly = %v, expected %v, %v",
+				test.line, pattern.negate, pattern.dirOnly, test.negate, test.dirOnly)
+		}
+		for _, path := range test.matches {
+			if !pattern.regexp.MatchString(path) {
+				t.Errorf("parseIgnorePattern(%q) does not match %q (%s)", test.line, path, pattern.regexp)
+			}
+		}
+		for _, path := range test.mismatches {
+			if pattern.regexp.MatchString(path) {
+				t.Errorf("parseIgnorePattern(%q) matches %q (%s)", test.line, path, pattern.regexp)
+			}
+		}
+	}
+}
+
+func TestIgnoreMatcherIgnored(t *testing.T) {
+	lines := []string{
+		"# Dependencies",
+		"node_modules/",
+		"*.log",
+		"!important.log",
+		"/tmp",
+		"cache/",
+		"!cache/keep.txt",
+	}
+	matcher := &ignoreMatcher{FileName: ".html5ignore", dirs: make(map[string]bool)}
+	for _, line := range lines {
+		if pattern, ok := parseIgnorePattern(line); ok {
+			matcher.patterns = append(matcher.patterns, pattern)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "", isDir: true, ignored: false},
+		{path: ".", isDir: true, ignored: false},
+		{path: "index.html", ignored: false},
+		{path: "node_modules", isDir: true, ignored: true},
+		{path: "node_modules", isDir: false, ignored: false},
+		{path: "node_modules/lib/index.js", ignored: true},
+		{path: "webapp/node_modules/lib.js", ignored: true},
+		{path: "debug.log", ignored: true},
+		{path: "logs/debug.log", ignored: true},
+		{path: "important.log", ignored: false},
+		{path: "logs/important.log", ignored: false},
+		{path: "tmp", isDir: true, ignored: true},
+		{path: "tmp/a.txt", ignored: true},
+		{path: "webapp/tmp", isDir: true, ignored: false},
+		{path: "cache/keep.txt", ignored: true},
+		{path: "/webapp/index.html/", ignored: false},
+	}
+	for _, test := range tests {
+		if ignored := matcher.Ignored(test.path, test.isDir); ignored != test.ignored {
+			t.Errorf("Ignored(%q, %v) = %v, expected %v", test.path, test.isDir, ignored, test.ignored)
+		}
+	}
+
+	var nilMatcher *ignoreMatcher
+	if nilMatcher.Ignored("debug.log", false) {
+		t.Errorf("Ignored of nil matcher should be false")
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		fileName string
+		ignored  string
+	}{
+		{files: map[string]string{}, fileName: ""},
+		{files: map[string]string{".cfignore": "*.log\n"}, fileName: ".cfignore", ignored: "a.log"},
+		{files: map[string]string{".cfignore": "*.log\n", ".html5ignore": "*.txt\n"}, fileName: ".html5ignore", ignored: "a.txt"},
+	}
+	for idx, test := range tests {
+		appPath, err := ioutil.TempDir("", "html5-ignore-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(appPath)
+		for name, content := range test.files {
+			if err = ioutil.WriteFile(filepath.Join(appPath, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		matcher, err := loadIgnoreFile(appPath)
+		if err != nil {
+			t.Errorf("#%d: loadIgnoreFile returned error: %s", idx, err.Error())
+			continue
+		}
+		if test.fileName == "" {
+			if matcher != nil {
+				t.Errorf("#%d: loadIgnoreFile returned matcher of %s, expected nil", idx, matcher.FileName)
+			}
+			continue
+		}
+		if matcher == nil || matcher.FileName != test.fileName {
+			t.Errorf("#%d: loadIgnoreFile returned %+v, expected matcher of %s", idx, matcher, test.fileName)
+			continue
+		}
+		if !matcher.Ignored(test.ignored, false) {
+			t.Errorf("#%d: %s should be ignored by %s", idx, test.ignored, test.fileName)
+		}
+	}
+}
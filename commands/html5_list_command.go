@@ -5,7 +5,6 @@ import (
 	"cf-html5-apps-repo-cli-plugin/clients/models"
 	"cf-html5-apps-repo-cli-plugin/log"
 	"cf-html5-apps-repo-cli-plugin/ui"
-	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -210,80 +209,13 @@ func (c *ListCommand) ListDestinationApps(destinationInstance string, showUrls b
 		return Failure
 	}
 
-	// Get Destination context
-	var destinationContext DestinationContext
-	if destinationInstance != "*" {
-		destinationContext, err = c.GetDestinationContext(context, destinationInstance)
-	} else {
-		destinationContext, err = c.GetDestinationContext(context, "")
-	}
+	// Get destinations
+	destinations, destinationContext, err := c.getDestinations(context, destinationInstance)
 	if err != nil {
 		ui.Failed(err.Error())
 		return Failure
 	}
 
-	var destinations models.DestinationListDestinationsResponse
-	if destinationInstance != "" {
-		if destinationInstance != "*" {
-			destinations, err = clients.ListServiceInstanceDestinations(
-				*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
-				destinationContext.DestinationServiceInstanceKeyToken)
-			if err != nil {
-				ui.Failed("Could not get list of service instance destinations: %s", err.Error())
-				return Failure
-			}
-			// Add destination service instance name to each destination
-			for idx := range destinations {
-				(&destinations[idx]).DestinationServiceInstanceName = destinationContext.DestinationServiceInstances[0].Name
-			}
-		} else {
-			for _, destinationServiceInstance := range destinationContext.DestinationServiceInstances {
-				var destinationInstanceContext DestinationContext
-				if destinationInstance != destinationServiceInstance.Name {
-					// Get Destination context
-					destinationInstanceContext, err = c.GetDestinationContext(context, destinationServiceInstance.Name)
-					if err != nil {
-						ui.Failed(err.Error())
-						return Failure
-					}
-				} else {
-					destinationInstanceContext = destinationContext
-				}
-				// List service instance destinations
-				destinationsList, err := clients.ListServiceInstanceDestinations(
-					*destinationInstanceContext.DestinationServiceInstanceKey.Credentials.URI,
-					destinationInstanceContext.DestinationServiceInstanceKeyToken)
-				if err != nil {
-					ui.Failed("Could not get list of service instance destinations: %s", err.Error())
-					return Failure
-				}
-				// Add destination service instance name to each destination
-				for _, destination := range destinationsList {
-					destination.DestinationServiceInstanceName = destinationServiceInstance.Name
-					log.Tracef("Setting destination service instance name to '%s' for destination %+v\n", destinationServiceInstance.Name, destination)
-					destinations = append(destinations, destination)
-				}
-				// Clean-up destination context
-				err = c.CleanDestinationContext(destinationInstanceContext)
-				if err != nil {
-					ui.Failed(err.Error())
-					return Failure
-				}
-			}
-		}
-		log.Tracef("List of service instance destinations: %+v\n", destinations)
-	} else {
-		// List subaccount destinations
-		destinations, err = clients.ListSubaccountDestinations(
-			*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
-			destinationContext.DestinationServiceInstanceKeyToken)
-		if err != nil {
-			ui.Failed("Could not get list of subaccount destinations: %s", err.Error())
-			return Failure
-		}
-		log.Tracef("List of subaccount destinations: %+v\n", destinations)
-	}
-
 	// Table columns
 	columns := make([]string, 0)
 	columns = append(columns, "name", "version", "app-host-id", "service name", "destination name", "destination service name", "last changed")
@@ -300,87 +232,58 @@ func (c *ListCommand) ListDestinationApps(destinationInstance string, showUrls b
 	// Iterate over business service destinations
 	for _, destination := range destinations {
 		log.Tracef("Processing destination: %+v\n", destination)
-		if serviceName, ok := destination.Properties["sap.cloud.service"]; ok {
-			log.Tracef("Destination '%s' has 'sap.cloud.service' property: %s\n", destination.Name, serviceName)
-			var ok bool
-			var appHostGUIDs string
-			appHostGUIDs, ok = destination.Properties["html5-apps-repo.app_host_id"]
-			if !ok {
-				appHostGUIDs, ok = destination.Properties["app_host_id"]
-			}
-			if !ok {
-				var html5AppsRepo string
-				if html5AppsRepo, ok = destination.Properties["html5-apps-repo"]; ok &&
-					len(html5AppsRepo) > 0 && html5AppsRepo[0:1] == "{" {
-
-					var html5RepoMap map[string]interface{}
-					var appHostGUIDsInterface interface{}
-					err = json.Unmarshal([]byte(html5AppsRepo), &html5RepoMap)
-					if err != nil {
-						log.Tracef("Could not parse 'hmtl5-apps-repo' property of destination '%s'", destination)
-						ok = false
-					} else if appHostGUIDsInterface, ok = html5RepoMap["app_host_id"]; ok {
-						switch v := appHostGUIDsInterface.(type) {
-						case string:
-							appHostGUIDs = v
-						}
-					}
-				}
-			}
-			if ok {
-				for _, appHostGUID := range strings.Split(appHostGUIDs, ",") {
-					appHostGUID = strings.Trim(appHostGUID, " ")
-					log.Tracef("Getting list of applications for app-host-id '%s' of service '%s' defined in destination with name '%s'\n",
-						appHostGUID,
-						serviceName,
-						destination.Name)
-					applications, err := clients.ListApplicationsForAppHost(
-						*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
-						html5Context.HTML5AppRuntimeServiceInstanceKeyToken, appHostGUID)
-					if err != nil {
-						// Invalid app-host-id
-						if strings.Index(err.Error(), "HTTP 400") >= 0 {
-							row := make([]string, len(columns))
-							row[0] = terminal.FailureColor("-")
-							row[1] = terminal.FailureColor("-")
-							row[2] = terminal.FailureColor(appHostGUID)
-							row[3] = terminal.FailureColor(serviceName)
-							row[4] = terminal.FailureColor(destination.Name)
-							row[5] = terminal.FailureColor("-")
-							row[6] = terminal.FailureColor(destination.DestinationServiceInstanceName)
-							if showUrls {
-								row[7] = terminal.FailureColor("-")
-							}
-							rows = append(rows, row)
-							continue
-						}
-						ui.Failed("Could not get list of applications for app-host-id '%s' of service '%s': %+v", appHostGUID, serviceName, err)
-						return Failure
-					}
-					log.Tracef("Got list of applications for app-host-id '%s' of service '%s' defined in destination with name '%s': %+v\n",
-						appHostGUID,
-						serviceName,
-						destination.Name,
-						applications)
-					for _, application := range applications {
+		if serviceName, appHostGUIDs, ok := getDestinationAppHostGUIDs(destination); ok {
+			for _, appHostGUID := range appHostGUIDs {
+				log.Tracef("Getting list of applications for app-host-id '%s' of service '%s' defined in destination with name '%s'\n",
+					appHostGUID,
+					serviceName,
+					destination.Name)
+				applications, err := clients.ListApplicationsForAppHost(
+					*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+					html5Context.HTML5AppRuntimeServiceInstanceKeyToken, appHostGUID)
+				if err != nil {
+					// Invalid app-host-id
+					if strings.Index(err.Error(), "HTTP 400") >= 0 {
 						row := make([]string, len(columns))
-						row[0] = application.ApplicationName
-						row[1] = application.ApplicationVersion
-						row[2] = appHostGUID
-						row[3] = serviceName
-						row[4] = destination.Name
-						row[5] = destination.DestinationServiceInstanceName
-						row[6] = application.ChangedOn
+						row[0] = terminal.FailureColor("-")
+						row[1] = terminal.FailureColor("-")
+						row[2] = terminal.FailureColor(appHostGUID)
+						row[3] = terminal.FailureColor(serviceName)
+						row[4] = terminal.FailureColor(destination.Name)
+						row[5] = terminal.FailureColor("-")
+						row[6] = terminal.FailureColor(destination.DestinationServiceInstanceName)
 						if showUrls {
-							destinationInstanceGUID := ""
-							if destinationInstance != "" {
-								destinationInstanceGUID = destinationContext.DestinationServiceInstances[0].GUID + "."
-							}
-							row[7] = html5Context.GetRuntimeURL(runtime) + "/" + destinationInstanceGUID + strings.Replace(serviceName, ".", "", -1) +
-								"." + application.ApplicationName + "-" + application.ApplicationVersion + "/"
+							row[7] = terminal.FailureColor("-")
 						}
 						rows = append(rows, row)
+						continue
 					}
+					ui.Failed("Could not get list of applications for app-host-id '%s' of service '%s': %+v", appHostGUID, serviceName, err)
+					return Failure
+				}
+				log.Tracef("Got list of applications for app-host-id '%s' of service '%s' defined in destination with name '%s': %+v\n",
+					appHostGUID,
+					serviceName,
+					destination.Name,
+					applications)
+				for _, application := range applications {
+					row := make([]string, len(columns))
+					row[0] = application.ApplicationName
+					row[1] = application.ApplicationVersion
+					row[2] = appHostGUID
+					row[3] = serviceName
+					row[4] = destination.Name
+					row[5] = destination.DestinationServiceInstanceName
+					row[6] = application.ChangedOn
+					if showUrls {
+						destinationInstanceGUID := ""
+						if destinationInstance != "" {
+							destinationInstanceGUID = destinationContext.DestinationServiceInstances[0].GUID + "."
+						}
+						row[7] = html5Context.GetRuntimeURL(runtime) + "/" + destinationInstanceGUID + strings.Replace(serviceName, ".", "", -1) +
+							"." + application.ApplicationName + "-" + application.ApplicationVersion + "/"
+					}
+					rows = append(rows, row)
 				}
 			}
 		}
@@ -495,34 +398,25 @@ func (c *ListCommand) ListAppApps(appName string, showUrls bool) ExecutionStatus
 	// Find services with app-host-id
 	var servicesData = Model{}
 	servicesData.Services = make([]Service, 0)
-	for serviceName, serviceBindings := range env.SystemEnvJSON.VCAPServices {
-		for _, serviceBinding := range serviceBindings {
-			if serviceBinding.Credentials.HTML5AppsRepo != nil {
-				AppHostIDs := strings.Split(serviceBinding.Credentials.HTML5AppsRepo.AppHostID, ",")
-				prefix := ""
-				if serviceBinding.Credentials.SAPCloudServiceAlias != nil {
-					prefix = *serviceBinding.Credentials.SAPCloudServiceAlias + "."
-				} else if serviceBinding.Credentials.SAPCloudService != nil {
-					prefix = strings.Replace(strings.Replace(*serviceBinding.Credentials.SAPCloudService, ".", "", -1), "-", "", -1) + "."
-				}
-				for _, appHostID := range AppHostIDs {
-					// Get list of applications for app-host-id
-					log.Tracef("Getting list of applications for service '%s' and app-host-id '%s'\n", serviceName, appHostID)
-					applications, err := clients.ListApplicationsForAppHost(*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
-						html5Context.HTML5AppRuntimeServiceInstanceKeyToken, appHostID)
-					if err != nil {
-						ui.Failed("Could not get list of applications for app-host-id '%s': %+v", appHostID, err)
-						return Failure
-					}
-					apps := make([]App, 0)
-					for _, app := range applications {
-						apps = append(apps, App{Name: app.ApplicationName, Version: app.ApplicationVersion, Changed: app.ChangedOn, Public: app.IsPublic})
-					}
+	for _, appHost := range getBoundAppHosts(env) {
+		// Get list of applications for app-host-id
+		log.Tracef("Getting list of applications for service '%s' and app-host-id '%s'\n", appHost.ServiceName, appHost.AppHostGUID)
+		applications, err := clients.ListApplicationsForAppHost(*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+			html5Context.HTML5AppRuntimeServiceInstanceKeyToken, appHost.AppHostGUID)
+		if err != nil {
+			ui.Failed("Could not get list of applications for app-host-id '%s': %+v", appHost.AppHostGUID, err)
+			return Failure
+		}
+		apps := make([]App, 0)
+		for _, app := range applications {
+			apps = append(apps, App{Name: app.ApplicationName, Version: app.ApplicationVersion, Changed: app.ChangedOn, Public: app.IsPublic})
+		}
 
-					servicesData.Services = append(servicesData.Services, Service{GUID: appHostID, Name: serviceName, Apps: apps, Prefix: prefix})
-				}
-			}
+		prefix := ""
+		if appHost.Prefix != "" {
+			prefix = appHost.Prefix + "."
 		}
+		servicesData.Services = append(servicesData.Services, Service{GUID: appHost.AppHostGUID, Name: appHost.ServiceName, Apps: apps, Prefix: prefix})
 	}
 
 	// Clean-up HTML5 context
@@ -0,0 +1,470 @@
+package commands
+
+import (
+	clients "cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// Default number of newest versions of each application kept by html5-prune
+const defaultKeepVersions = 3
+
+// PruneCommand delete old versions of HTML5 applications
+// from app-host service instance
+type PruneCommand struct {
+	HTML5Command
+}
+
+// PruneApp application version with pruning decision
+type PruneApp struct {
+	Name    string
+	Version string
+	Default bool
+	Keep    bool
+	Reason  string
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *PruneCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "html5-prune",
+		HelpText: "Delete old versions of HTML5 applications from app-host service instance",
+		UsageDetails: plugin.Usage{
+			Usage: "cf html5-prune APP_HOST_ID|-n APP_HOST_NAME [--keep NUMBER] [--dry-run]",
+			Options: map[string]string{
+				"-keep":         "Number of newest versions of each application to keep. Default version is always kept. Default value is 3",
+				"-dry-run":      "Print versions to be deleted without deleting them",
+				"-name,-n":      "Use app-host service instance with specified name",
+				"APP_HOST_ID":   "GUID of html5-apps-repo app-host service instance",
+				"APP_HOST_NAME": "Name of html5-apps-repo app-host service instance",
+			},
+		},
+	}
+}
+
+// Execute executes plugin command
+func (c *PruneCommand) Execute(args []string) ExecutionStatus {
+	log.Tracef("Executing command '%s': args: '%v'\n", c.Name, args)
+
+	flagSet := flag.NewFlagSet("html5-prune", flag.ContinueOnError)
+	keepFlag := flagSet.Int("keep", defaultKeepVersions, "number of newest versions to keep")
+	dryRunFlag := flagSet.Bool("dry-run", false, "print versions to be deleted")
+	nameFlag := flagSet.String("name", "", "app-host service instance name")
+	nameFlagAlias := flagSet.String("n", "", "app-host service instance name")
+	err := flagSet.Parse(args)
+	if err != nil {
+		ui.Failed("Could not parse arguments: %+v", err)
+		return Failure
+	}
+
+	// Normalize aliases
+	appHostName := *nameFlagAlias
+	if *nameFlag != "" {
+		appHostName = *nameFlag
+	}
+
+	if *keepFlag < 0 {
+		ui.Failed("Number of versions to keep should not be negative")
+		return Failure
+	}
+
+	if appHostName != "" && flagSet.NArg() == 0 {
+		return c.PruneAppHost(appHostName, true, *keepFlag, *dryRunFlag)
+	}
+	if appHostName == "" && flagSet.NArg() == 1 {
+		return c.PruneAppHost(flagSet.Arg(0), false, *keepFlag, *dryRunFlag)
+	}
+
+	ui.Failed("Incorrect number of arguments passed. See [cf html5-prune --help] for more details")
+	return Failure
+}
+
+// PruneAppHost delete all versions of applications of app-host, except
+// newest versions and default version
+func (c *PruneCommand) PruneAppHost(appHostNameOrGUID string, isName bool, keep int, dryRun bool) ExecutionStatus {
+	log.Tracef("Pruning applications of app-host '%s', keeping %d versions\n", appHostNameOrGUID, keep)
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	actionMessage := "Pruning"
+	if dryRun {
+		actionMessage = "Planning pruning of"
+	}
+	ui.Say("%s applications of app-host %s keeping %d newest versions in org %s / space %s as %s...",
+		actionMessage,
+		terminal.EntityNameColor(appHostNameOrGUID),
+		keep,
+		terminal.EntityNameColor(context.Org),
+		terminal.EntityNameColor(context.Space),
+		terminal.EntityNameColor(context.Username))
+
+	// Get HTML5 context
+	html5Context, err := c.GetHTML5Context(context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	appHostGUID := appHostNameOrGUID
+	if isName {
+		// Resolve app-host-id
+		log.Tracef("Resolving app-host-id by service instance name '%s'\n", appHostNameOrGUID)
+		serviceInstance, err := clients.GetServiceInstanceByName(c.CliConnection, context.SpaceID, appHostNameOrGUID)
+		if err != nil {
+			ui.Failed("%+v", err)
+			return Failure
+		}
+		log.Tracef("Resolved app-host-id is '%s'\n", serviceInstance.GUID)
+		appHostGUID = serviceInstance.GUID
+	}
+
+	// Get list of applications for app-host-id
+	log.Tracef("Getting list of applications for app-host-id %s\n", appHostGUID)
+	applications, err := clients.ListApplicationsForAppHost(*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+		html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+		appHostGUID)
+	if err != nil {
+		ui.Failed("Could not get list of applications for app-host-id %s: %+v", appHostGUID, err)
+		return Failure
+	}
+
+	// Decide which versions to keep
+	apps := getPruneApps(applications, keep)
+	keptApps := make([]PruneApp, 0)
+	deletedApps := make([]PruneApp, 0)
+	for _, app := range apps {
+		if app.Keep {
+			keptApps = append(keptApps, app)
+		} else {
+			deletedApps = append(deletedApps, app)
+		}
+	}
+
+	// Nothing to delete
+	if len(deletedApps) == 0 {
+		err = c.CleanHTML5Context(html5Context)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		ui.Ok()
+		ui.Say("")
+		ui.Say("Nothing to prune. App-host %s contains %d application versions", appHostGUID, len(apps))
+		ui.Say("")
+		return Success
+	}
+
+	// Print deployment plan
+	if dryRun {
+		deletedSize := 0
+		for _, app := range deletedApps {
+			files, err := getDeployedAppFiles(html5Context, appHostGUID, app.Name+"-"+app.Version)
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
+			for _, file := range files {
+				deletedSize += file.FileMetadata.FileSize
+			}
+		}
+		err = c.CleanHTML5Context(html5Context)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		ui.Ok()
+		ui.Say("")
+		printPruneApps(apps)
+		ui.Say("%d of %d application versions would be deleted, reclaiming about %s",
+			len(deletedApps), len(apps), getReadableSize(deletedSize))
+		ui.Say("")
+		return Success
+	}
+
+	// Create service key for DT
+	log.Tracef("Creating service key for app-host-id '%s'\n", appHostGUID)
+	serviceKey, err := clients.CreateServiceKey(c.CliConnection, appHostGUID, nil)
+	if err != nil {
+		ui.Failed("Could not create service key for service instance with id '%s' : %+v", appHostGUID, err)
+		return Failure
+	}
+	serviceKeyDeleted := false
+	defer func() {
+		if serviceKeyDeleted {
+			return
+		}
+		log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+		err := clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+		if err != nil {
+			ui.Warn("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+		}
+	}()
+
+	// Obtain access token
+	log.Tracef("Obtaining access token for service key '%s'\n", serviceKey.Name)
+	token, err := clients.GetToken(serviceKey.Credentials)
+	if err != nil {
+		ui.Failed("Could not obtain access token for service key '%s': %+v", serviceKey.Name, err)
+		return Failure
+	}
+
+	// Get app-host size before pruning
+	metaBefore, err := getAppHostMeta(*serviceKey.Credentials.URI, token, appHostGUID)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Download and zip versions to keep
+	tmp, err := ioutil.TempDir("", "html5-prune-")
+	if err != nil {
+		ui.Failed("Could not create temporary directory: %+v", err)
+		return Failure
+	}
+	// Temporary directory is kept, if content of app-host was deleted,
+	// but downloaded applications could not be uploaded again
+	keepTmp := false
+	defer func() {
+		if keepTmp {
+			return
+		}
+		log.Tracef("Deleting temporary directory '%s'\n", tmp)
+		os.RemoveAll(tmp)
+	}()
+	zipFiles := make([]string, 0)
+	for _, app := range keptApps {
+		appKey := app.Name + "-" + app.Version
+		log.Tracef("Downloading application %s\n", appKey)
+		files, err := clients.ListFilesOfApp(
+			*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+			appKey,
+			html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+			appHostGUID)
+		if err != nil {
+			ui.Failed("Could not get list of files for app %s: %+v", appKey, err)
+			return Failure
+		}
+		_, err = downloadAppFiles(html5Context, appHostGUID, files, tmp, false)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		zipPath := filepath.Join(tmp, appKey+".zip")
+		_, err = zipAppDirectory(filepath.Join(tmp, appKey), zipPath, nil, nil)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		zipFiles = append(zipFiles, zipPath)
+	}
+
+	// Delete content of app-host
+	log.Tracef("Deleting content of app-host-id '%s'\n", appHostGUID)
+	keepTmp = true
+	err = clients.DeleteServiceContent(*serviceKey.Credentials.URI, token)
+	if err != nil {
+		ui.Failed("Could not delete content of app-host-id '%s': %+v. "+
+			"Downloaded applications are preserved in %s and can be pushed with 'cf html5-push %s %s'",
+			appHostGUID, err, tmp, tmp, appHostGUID)
+		return Failure
+	}
+
+	// Upload versions to keep
+	if len(zipFiles) > 0 {
+		err = clients.UploadAppHost(*serviceKey.Credentials.URI, zipFiles, token, newUploadProgressReporter())
+		if err != nil {
+			ui.Failed("Could not upload applications to app-host-id '%s': %+v. "+
+				"Downloaded applications are preserved in %s and can be pushed with 'cf html5-push %s %s'",
+				appHostGUID, err, tmp, tmp, appHostGUID)
+			return Failure
+		}
+	}
+	keepTmp = false
+
+	// Check that default versions were not changed by re-upload
+	log.Tracef("Checking default versions of applications for app-host-id %s\n", appHostGUID)
+	applicationsAfter, err := clients.ListApplicationsForAppHost(*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+		html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+		appHostGUID)
+	if err != nil {
+		ui.Failed("Could not get list of applications for app-host-id %s: %+v", appHostGUID, err)
+		return Failure
+	}
+	changedDefaults := getChangedDefaultVersions(applications, applicationsAfter)
+	if len(changedDefaults) > 0 {
+		ui.Failed("Default versions of applications were changed after pruning app-host-id '%s': %s",
+			appHostGUID, strings.Join(changedDefaults, ", "))
+		return Failure
+	}
+
+	// Get app-host size after pruning
+	metaAfter, err := getAppHostMeta(*serviceKey.Credentials.URI, token, appHostGUID)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Delete temporary files
+	log.Tracef("Deleting temporary directory '%s'\n", tmp)
+	err = os.RemoveAll(tmp)
+	if err != nil {
+		ui.Failed("Could not delete temporary directory '%s': %+v", tmp, err)
+		return Failure
+	}
+
+	// Delete temporarry service keys
+	log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+	err = clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+	serviceKeyDeleted = true
+	if err != nil {
+		ui.Failed("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+		return Failure
+	}
+
+	// Clean-up HTML5 context
+	err = c.CleanHTML5Context(html5Context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	ui.Ok()
+	ui.Say("")
+	printPruneApps(apps)
+	ui.Say("Deleted %d of %d application versions. Reclaimed %s (size before: %s, size after: %s, size limit: %s)",
+		len(deletedApps),
+		len(apps),
+		getReadableSize(metaBefore.Size-metaAfter.Size),
+		getReadableSize(metaBefore.Size),
+		getReadableSize(metaAfter.Size),
+		getReadableSize(metaAfter.SizeLimit))
+	ui.Say("")
+
+	return Success
+}
+
+// getPruneApps sorts versions of each application from newest to oldest
+// and marks newest versions and default version to be kept
+func getPruneApps(applications models.HTML5ListApplicationsResponse, keep int) []PruneApp {
+	names := make([]string, 0)
+	versions := make(map[string][]models.HTML5App)
+	for _, application := range applications {
+		if _, ok := versions[application.ApplicationName]; !ok {
+			names = append(names, application.ApplicationName)
+		}
+		versions[application.ApplicationName] = append(versions[application.ApplicationName], application)
+	}
+	sort.Strings(names)
+
+	apps := make([]PruneApp, 0)
+	for _, name := range names {
+		appVersions := versions[name]
+		sort.SliceStable(appVersions, func(i, j int) bool {
+			return compareVersions(appVersions[i].ApplicationVersion, appVersions[j].ApplicationVersion) > 0
+		})
+		for idx, application := range appVersions {
+			app := PruneApp{Name: name, Version: application.ApplicationVersion, Default: application.IsDefault}
+			if idx < keep {
+				app.Keep = true
+				app.Reason = "newest"
+			} else if application.IsDefault {
+				app.Keep = true
+				app.Reason = "default"
+			}
+			apps = append(apps, app)
+		}
+	}
+
+	return apps
+}
+
+// getChangedDefaultVersions returns descriptions of applications, which
+// default version before differs from default version after
+func getChangedDefaultVersions(before models.HTML5ListApplicationsResponse, after models.HTML5ListApplicationsResponse) []string {
+	defaultsAfter := make(map[string]string)
+	for _, application := range after {
+		if application.IsDefault {
+			defaultsAfter[application.ApplicationName] = application.ApplicationVersion
+		}
+	}
+	changed := make([]string, 0)
+	for _, application := range before {
+		if !application.IsDefault {
+			continue
+		}
+		version, ok := defaultsAfter[application.ApplicationName]
+		if !ok {
+			version = "none"
+		}
+		if version != application.ApplicationVersion {
+			changed = append(changed, fmt.Sprintf("%s (%s instead of %s)",
+				application.ApplicationName, version, application.ApplicationVersion))
+		}
+	}
+	return changed
+}
+
+// compareVersions compares application versions semantically. Versions,
+// which are not valid semantic versions, are considered older. Versions,
+// which are semantically equal, are compared as strings
+func compareVersions(a string, b string) int {
+	versionA, errA := semver.ParseTolerant(a)
+	versionB, errB := semver.ParseTolerant(b)
+	switch {
+	case errA == nil && errB == nil && versionA.Compare(versionB) != 0:
+		return versionA.Compare(versionB)
+	case errA == nil && errB != nil:
+		return 1
+	case errA != nil && errB == nil:
+		return -1
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+// printPruneApps prints table of application versions with pruning decision
+func printPruneApps(apps []PruneApp) {
+	table := ui.Table([]string{"name", "version", "default", "action"})
+	for _, app := range apps {
+		action := "delete"
+		if app.Keep {
+			action = "keep (" + app.Reason + ")"
+		}
+		table.Add(app.Name, app.Version, (map[bool]string{true: "yes", false: "no"})[app.Default], action)
+	}
+	table.Print()
+	ui.Say("")
+}
+
+// getAppHostMeta get size and size limit of app-host
+func getAppHostMeta(serviceURL string, accessToken string, appHostGUID string) (models.HTML5ServiceMeta, error) {
+	log.Tracef("Getting information about service with app-host-id '%s'\n", appHostGUID)
+	infoChan := make(chan models.HTML5ServiceMeta)
+	go clients.GetServiceMeta(serviceURL, accessToken, infoChan)
+	meta := <-infoChan
+	if meta.Error != nil {
+		return meta, fmt.Errorf("Could not get information about app-host-id '%s': %+v", appHostGUID, meta.Error)
+	}
+	return meta, nil
+}
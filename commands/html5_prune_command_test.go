@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "1.0.0", b: "1.0.0", expected: 0},
+		{a: "1.0.1", b: "1.0.0", expected: 1},
+		{a: "1.0.0", b: "1.0.1", expected: -1},
+		{a: "1.10.0", b: "1.9.0", expected: 1},
+		{a: "2.0.0", b: "10.0.0", expected: -1},
+		{a: "1.0.0", b: "1.0.0-beta", expected: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-beta", expected: -1},
+		{a: "v1.2", b: "1.1.9", expected: 1},
+		{a: "1", b: "1.0.0", expected: -1},
+		{a: "1.0.0", b: "1", expected: 1},
+		{a: "v1.0.0", b: "1.0.0", expected: 1},
+		{a: "1.0.0", b: "latest", expected: 1},
+		{a: "latest", b: "0.0.1", expected: -1},
+		{a: "b", b: "a", expected: 1},
+		{a: "a", b: "b", expected: -1},
+		{a: "a", b: "a", expected: 0},
+	}
+	for _, test := range tests {
+		if result := compareVersions(test.a, test.b); result != test.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", test.a, test.b, result, test.expected)
+		}
+	}
+}
+
+func TestGetPruneApps(t *testing.T) {
+	tests := []struct {
+		name         string
+		applications models.HTML5ListApplicationsResponse
+		keep         int
+		expected     []PruneApp
+	}{
+		{
+			name:     "no applications",
+			keep:     1,
+			expected: []PruneApp{},
+		},
+		{
+			name: "keep newest",
+			applications: models.HTML5ListApplicationsResponse{
+				{ApplicationName: "app", ApplicationVersion: "1.9.0"},
+				{ApplicationName: "app", ApplicationVersion: "1.10.0"},
+				{ApplicationName: "app", ApplicationVersion: "1.0.0"},
+			},
+			keep: 2,
+			expected: []PruneApp{
+				{Name: "app", Version: "1.10.0", Keep: true, Reason: "newest"},
+				{Name: "app", Version: "1.9.0", Keep: true, Reason: "newest"},
+				{Name: "app", Version: "1.0.0"},
+			},
+		},
+		{
+			name: "keep default",
+			applications: models.HTML5ListApplicationsResponse{
+				{ApplicationName: "app", ApplicationVersion: "1.0.0", IsDefault: true},
+				{ApplicationName: "app", ApplicationVersion: "2.0.0"},
+				{ApplicationName: "app", ApplicationVersion: "3.0.0"},
+			},
+			keep: 1,
+			expected: []PruneApp{
+				{Name: "app", Version: "3.0.0", Keep: true, Reason: "newest"},
+				{Name: "app", Version: "2.0.0"},
+				{Name: "app", Version: "1.0.0", Default: true, Keep: true, Reason: "default"},
+			},
+		},
+		{
+			name: "keep none",
+			applications: models.HTML5ListApplicationsResponse{
+				{ApplicationName: "app", ApplicationVersion: "1.0.0"},
+				{ApplicationName: "app", ApplicationVersion: "2.0.0", IsDefault: true},
+			},
+			keep: 0,
+			expected: []PruneApp{
+				{Name: "app", Version: "2.0.0", Default: true, Keep: true, Reason: "default"},
+				{Name: "app", Version: "1.0.0"},
+			},
+		},
+		{
+			name: "multiple applications",
+			applications: models.HTML5ListApplicationsResponse{
+				{ApplicationName: "b", ApplicationVersion: "1.0.0"},
+				{ApplicationName: "a", ApplicationVersion: "snapshot"},
+				{ApplicationName: "a", ApplicationVersion: "1.0.0"},
+				{ApplicationName: "b", ApplicationVersion: "1.0.1"},
+			},
+			keep: 1,
+			expected: []PruneApp{
+				{Name: "a", Version: "1.0.0", Keep: true, Reason: "newest"},
+				{Name: "a", Version: "snapshot"},
+				{Name: "b", Version: "1.0.1", Keep: true, Reason: "newest"},
+				{Name: "b", Version: "1.0.0"},
+			},
+		},
+		{
+			name: "semantically equal versions",
+			applications: models.HTML5ListApplicationsResponse{
+				{ApplicationName: "app", ApplicationVersion: "1"},
+				{ApplicationName: "app", ApplicationVersion: "1.0.0"},
+				{ApplicationName: "app", ApplicationVersion: "v1.0.0"},
+			},
+			keep: 1,
+			expected: []PruneApp{
+				{Name: "app", Version: "v1.0.0", Keep: true, Reason: "newest"},
+				{Name: "app", Version: "1.0.0"},
+				{Name: "app", Version: "1"},
+			},
+		},
+	}
+	for _, test := range tests {
+		apps := getPruneApps(test.applications, test.keep)
+		if !reflect.DeepEqual(apps, test.expected) {
+			t.Errorf("%s: getPruneApps returned %+v, expected %+v", test.name, apps, test.expected)
+		}
+	}
+}
+
+func TestGetChangedDefaultVersions(t *testing.T) {
+	before := models.HTML5ListApplicationsResponse{
+		{ApplicationName: "a", ApplicationVersion: "1.0.0", IsDefault: true},
+		{ApplicationName: "a", ApplicationVersion: "2.0.0"},
+		{ApplicationName: "b", ApplicationVersion: "1.0.0", IsDefault: true},
+		{ApplicationName: "c", ApplicationVersion: "1.0.0", IsDefault: true},
+		{ApplicationName: "d", ApplicationVersion: "1.0.0"},
+	}
+	after := models.HTML5ListApplicationsResponse{
+		{ApplicationName: "a", ApplicationVersion: "1.0.0"},
+		{ApplicationName: "a", ApplicationVersion: "2.0.0", IsDefault: true},
+		{ApplicationName: "b", ApplicationVersion: "1.0.0", IsDefault: true},
+		{ApplicationName: "c", ApplicationVersion: "1.0.0"},
+		{ApplicationName: "d", ApplicationVersion: "1.0.0", IsDefault: true},
+	}
+	expected := []string{"a (2.0.0 instead of 1.0.0)", "c (none instead of 1.0.0)"}
+	if changed := getChangedDefaultVersions(before, after); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("getChangedDefaultVersions returned %v, expected %v", changed, expected)
+	}
+	if changed := getChangedDefaultVersions(before, before); len(changed) != 0 {
+		t.Errorf("getChangedDefaultVersions of same applications returned %v", changed)
+	}
+}
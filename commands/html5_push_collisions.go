@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"fmt"
+	"strings"
+)
+
+// normalizeAppName returns name of application in HTML5 Application
+// Repository for sap.app/id (dots and dashes are removed)
+func normalizeAppName(appID string) string {
+	appName := strings.Replace(appID, ".", "", -1)
+	return strings.Replace(appName, "-", "", -1)
+}
+
+// findBatchCollisions returns descriptions of applications pushed together,
+// which have different sap.app/id, but the same normalized name
+func findBatchCollisions(appIDs []string, appIDDirs map[string][]string) []string {
+	collisions := make([]string, 0)
+	names := make([]string, 0)
+	nameAppIDs := make(map[string][]string)
+	for _, appID := range appIDs {
+		appName := normalizeAppName(appID)
+		if _, ok := nameAppIDs[appName]; !ok {
+			names = append(names, appName)
+		}
+		nameAppIDs[appName] = append(nameAppIDs[appName], appID)
+	}
+	for _, appName := range names {
+		if len(nameAppIDs[appName]) < 2 {
+			continue
+		}
+		sources := make([]string, 0)
+		for _, appID := range nameAppIDs[appName] {
+			sources = append(sources, fmt.Sprintf("sap.app/id '%s' in %s", appID, strings.Join(appIDDirs[appID], ", ")))
+		}
+		collisions = append(collisions, fmt.Sprintf("'%s' is the name of %s", appName, strings.Join(sources, " and ")))
+	}
+	return collisions
+}
+
+// findDeployedCollisions returns descriptions of applications, which names
+// are already used by applications of other app-host service instances in space
+func (c *PushCommand) findDeployedCollisions(html5Context HTML5Context, spaceGUID string, appHostGUID string,
+	appNames []string, dirs []string) ([]string, error) {
+	collisions := make([]string, 0)
+
+	// Find app-host service plan
+	var appHostServicePlan *models.CFServicePlan
+	for _, plan := range html5Context.HTML5AppsRepoServicePlans {
+		if plan.Name == "app-host" {
+			appHostServicePlan = &plan
+			break
+		}
+	}
+	if appHostServicePlan == nil {
+		return collisions, fmt.Errorf("Could not find app-host service plan")
+	}
+
+	// Get list of service instances of app-host plan
+	log.Tracef("Getting service instances of %s service app-host plan (%+v)\n", html5Context.ServiceName, appHostServicePlan)
+	appHostServiceInstances, err := clients.GetServiceInstances(c.CliConnection, spaceGUID, []models.CFServicePlan{*appHostServicePlan})
+	if err != nil {
+		return collisions, fmt.Errorf("Could not get service instances for app-host plan: %+v", err)
+	}
+
+	// Look for application names in other app-host service instances
+	for _, serviceInstance := range appHostServiceInstances {
+		if serviceInstance.GUID == appHostGUID {
+			continue
+		}
+		log.Tracef("Getting list of applications for app-host-id %s\n", serviceInstance.GUID)
+		applications, err := clients.ListApplicationsForAppHost(
+			*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+			html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+			serviceInstance.GUID)
+		if err != nil {
+			return collisions, fmt.Errorf("Could not get list of applications for app-host instance %s: %+v", serviceInstance.Name, err)
+		}
+		for idx, appName := range appNames {
+			for _, application := range applications {
+				if application.ApplicationName == appName {
+					collisions = append(collisions, fmt.Sprintf("'%s' of %s is already deployed to app-host %s (%s)",
+						appName, dirs[idx], serviceInstance.Name, serviceInstance.GUID))
+					break
+				}
+			}
+		}
+	}
+
+	return collisions, nil
+}
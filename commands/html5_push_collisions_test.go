@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAppName(t *testing.T) {
+	tests := map[string]string{
+		"my.app":      "myapp",
+		"my-app":      "myapp",
+		"my.app-1.0":  "myapp10",
+		"my_app":      "my_app",
+		"":            "",
+		"..--":        "",
+		"com.sap.App": "comsapApp",
+	}
+	for appID, expected := range tests {
+		if appName := normalizeAppName(appID); appName != expected {
+			t.Errorf("normalizeAppName(%q) = %q, expected %q", appID, appName, expected)
+		}
+	}
+}
+
+func TestFindBatchCollisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		appIDs    []string
+		appIDDirs map[string][]string
+		expected  []string
+	}{
+		{
+			name:     "no applications",
+			expected: []string{},
+		},
+		{
+			name:      "different names",
+			appIDs:    []string{"my.app", "my.other"},
+			appIDDirs: map[string][]string{"my.app": {"app"}, "my.other": {"other"}},
+			expected:  []string{},
+		},
+		{
+			name:      "same sap.app/id in several directories",
+			appIDs:    []string{"my.app"},
+			appIDDirs: map[string][]string{"my.app": {"app1", "app2"}},
+			expected:  []string{},
+		},
+		{
+			name:      "same normalized name",
+			appIDs:    []string{"my.app", "my-app", "myapp", "other.app", "other-app"},
+			appIDDirs: map[string][]string{"my.app": {"app1", "app2"}, "my-app": {"app3"}, "myapp": {"app4"}, "other.app": {"other1"}, "other-app": {"other2"}},
+			expected: []string{
+				"'myapp' is the name of sap.app/id 'my.app' in app1, app2 and sap.app/id 'my-app' in app3 and sap.app/id 'myapp' in app4",
+				"'otherapp' is the name of sap.app/id 'other.app' in other1 and sap.app/id 'other-app' in other2",
+			},
+		},
+	}
+	for _, test := range tests {
+		if collisions := findBatchCollisions(test.appIDs, test.appIDDirs); !reflect.DeepEqual(collisions, test.expected) {
+			t.Errorf("%s: findBatchCollisions returned %q, expected %q", test.name, collisions, test.expected)
+		}
+	}
+}
@@ -21,19 +21,74 @@ import (
 	"github.com/cloudfoundry/cli/plugin"
 )
 
+// Default maximum depth of recursive search for applications
+const defaultSearchDepth = 5
+
+// Metadata label of xsuaa service instances created for destinations,
+// which value is GUID of app-host service instance
+const xsuaaAppHostLabel = "html5-apps-repo-app-host-id"
+
+// Directories skipped during recursive search for applications
+var skippedDirectories = []string{"node_modules", "bower_components", ".git", ".svn", ".hg", ".cache"}
+
 // PushCommand fetches the HTML5 application
 // file contents
 type PushCommand struct {
 	HTML5Command
 }
 
+// PushOptions options of html5-push command
+type PushOptions struct {
+	// Redeploy applications to app-host, which already contains them
+	Redeploy bool
+	// Create subaccount level destination
+	Destination bool
+	// Name of business service instance, which credentials are used in destination
+	BusinessService string
+	// Name of destination service instance for service instance level destination
+	DestinationInstance string
+	// Runtime for which conventional URLs of applications are shown
+	Runtime string
+	// Print deployment plan instead of pushing applications
+	DryRun bool
+	// Look for applications in subdirectories recursively
+	Recursive bool
+	// Maximum depth of recursive search
+	Depth int
+	// Upload only applications, which differ from deployed versions
+	Incremental bool
+	// Name of app-host service instance, if new one is created
+	AppHostName string
+	// Parameters of app-host service instance, if new one is created
+	AppHostParams interface{}
+	// Snapshot content of app-host before upload
+	Snapshot bool
+	// Path to XSUAA security descriptor (xs-security.json) merged with
+	// scopes of applications, when destination is created
+	SecurityDescriptor string
+	// Do not update existing destinations
+	NoUpdate bool
+	// Name of created destination
+	DestinationName string
+	// Description of created destination
+	DestinationDescription string
+	// Additional properties of created destination
+	DestinationProperties map[string]string
+	// Push applications, which names collide with other applications
+	AllowShadowing bool
+	// Changes of application files written into uploaded archives
+	Transform *appTransformer
+	// Compare deployed files with uploaded files after upload
+	Verify bool
+}
+
 // GetPluginCommand returns the plugin command details
 func (c *PushCommand) GetPluginCommand() plugin.Command {
 	return plugin.Command{
 		Name:     "html5-push",
 		HelpText: "Push HTML5 applications to html5-apps-repo service",
 		UsageDetails: plugin.Usage{
-			Usage: "cf html5-push [-d|-di DESTINATION_SERVICE_INSTANCE_NAME|-s SERVICE_INSTANCE_NAME] [-rt RUNTIME] [-r|-n APP_HOST_NAME] [PATH_TO_APP_FOLDER ...] [APP_HOST_ID]",
+			Usage: "cf html5-push [-d|-di DESTINATION_SERVICE_INSTANCE_NAME|-s SERVICE_INSTANCE_NAME] [-rt RUNTIME] [-r|-n APP_HOST_NAME] [--app-host-name NEW_APP_HOST_NAME] [--app-host-params PARAMS] [--dry-run] [--incremental] [--snapshot] [--security-descriptor PATH] [--no-update] [--destination-name NAME] [--destination-description DESCRIPTION] [--destination-property KEY=VALUE ...] [--allow-shadowing] [--set-version VERSION] [--version-suffix SUFFIX] [--set-service SERVICE] [--vars-file PATH] [--vars-env] [--vars-glob PATTERN ...] [--verify] [--recursive [--depth DEPTH]] [PATH_TO_APP_FOLDER ...] [APP_HOST_ID]",
 			Options: map[string]string{
 				"-destination,-d":                   "Create subaccount level destination with credentials to access HTML5 applications",
 				"-destination-instance, -di":        "Create service instance level destination with credentials to access HTML5 applications",
@@ -41,8 +96,28 @@ func (c *PushCommand) GetPluginCommand() plugin.Command {
 				"-service,-s":                       "Create subaccount level destination with credentials of the service instance",
 				"-name,-n":                          "Use app-host service instance with specified name",
 				"-redeploy,-r":                      "Redeploy HTML5 applications. All applications should be previously deployed to same service instance",
+				"-app-host-name":                    "Name of app-host service instance, if new one is created. Can be set with 'appHostName' property of html5-push.json file in current working directory",
+				"-app-host-params":                  "Parameters of app-host service instance, if new one is created, as inline JSON object or path to JSON file. Can be set with 'appHostParams' property of html5-push.json file in current working directory",
+				"-dry-run":                          "Print deployment plan without creating service instances, service keys, destinations or uploading applications",
+				"-incremental":                      "Upload only applications, which content differs from the same application version deployed to app-host",
+				"-snapshot":                         "Save current content of app-host to local snapshot store before upload, so it can be restored with 'cf html5-rollback'",
+				"-destination-name":                 "Name of created destination. Default value is sap.cloud.service without dots",
+				"-destination-description":          "Description of created destination. Default value is 'Business Service Destination'",
+				"-destination-property":             "Additional property of created destination as key=value. Can be repeated",
+				"-no-update":                        "Do not update existing destinations, which configuration differs from the desired one",
+				"-security-descriptor":              "Path to xs-security.json merged with scopes of applications, when xsuaa service instance for destination is created. By default xs-security.json of application folders is used",
+				"-allow-shadowing":                  "Push applications, which names (sap.app/id without dots and dashes) collide with each other or with applications of other app-host service instances in the space",
+				"-set-version":                      "Override sap.app/applicationVersion/version of manifest.json in uploaded archives. Source files are not changed",
+				"-version-suffix":                   "Append suffix to sap.app/applicationVersion/version of manifest.json in uploaded archives (e.g. '+build.42'). Source files are not changed",
+				"-set-service":                      "Override sap.cloud/service of manifest.json in uploaded archives. Source files are not changed",
+				"-vars-file":                        "Path to JSON or YAML file with values of variables substituted for ${VAR} placeholders in manifest.json, xs-app.json and files matching --vars-glob patterns of uploaded archives",
+				"-vars-env":                         "Substitute ${VAR} placeholders with values of environment variables. Values from --vars-file take precedence",
+				"-vars-glob":                        "Pattern (.gitignore syntax) of additional application files, in which ${VAR} placeholders are substituted. Can be repeated",
+				"-verify":                           "After upload compare paths and sizes of deployed files with uploaded files, and fail if any file is missing or mismatched",
+				"-recursive":                        "Look for applications in subdirectories of current working directory or PATH_TO_APP_FOLDER recursively",
+				"-depth":                            "Maximum depth of recursive search for applications. Default value is 5",
 				"APP_HOST_NAME":                     "Name of app-host service instance to which applications should be deployed",
-				"PATH_TO_APP_FOLDER":                "One or multiple paths to folders containing manifest.json and xs-app.json files",
+				"PATH_TO_APP_FOLDER":                "One or multiple paths (or glob patterns, e.g. 'apps/*/dist') to folders or zip archives containing manifest.json and xs-app.json files, or to folders with such zip archives",
 				"APP_HOST_ID":                       "GUID of html5-apps-repo app-host service instance that contains application with specified name and version",
 				"DESTINATION_SERVICE_INSTANCE_NAME": "Name of destination service intance",
 			},
@@ -68,6 +143,28 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 	redeployFlagAlias := flagSet.Bool("r", false, "redeploy HTML5 applications")
 	nameFlag := flagSet.String("name", "", "app-host service instance name")
 	nameFlagAlias := flagSet.String("n", "", "app-host service instance name")
+	appHostNameFlag := flagSet.String("app-host-name", "", "name of new app-host service instance")
+	appHostParamsFlag := flagSet.String("app-host-params", "", "parameters of new app-host service instance")
+	dryRunFlag := flagSet.Bool("dry-run", false, "print deployment plan without pushing")
+	incrementalFlag := flagSet.Bool("incremental", false, "upload only changed applications")
+	snapshotFlag := flagSet.Bool("snapshot", false, "snapshot content of app-host before upload")
+	securityDescriptorFlag := flagSet.String("security-descriptor", "", "path to xs-security.json")
+	noUpdateFlag := flagSet.Bool("no-update", false, "do not update existing destinations")
+	destinationNameFlag := flagSet.String("destination-name", "", "name of destination")
+	destinationDescriptionFlag := flagSet.String("destination-description", "", "description of destination")
+	allowShadowingFlag := flagSet.Bool("allow-shadowing", false, "push applications with colliding names")
+	setVersionFlag := flagSet.String("set-version", "", "override application version")
+	versionSuffixFlag := flagSet.String("version-suffix", "", "append suffix to application version")
+	setServiceFlag := flagSet.String("set-service", "", "override business service name")
+	varsFileFlag := flagSet.String("vars-file", "", "path to values file")
+	varsEnvFlag := flagSet.Bool("vars-env", false, "substitute placeholders with environment variables")
+	verifyFlag := flagSet.Bool("verify", false, "verify deployed files after upload")
+	var varsGlobFlags stringSlice
+	flagSet.Var(&varsGlobFlags, "vars-glob", "pattern of files with placeholders")
+	var destinationPropertyFlags stringSlice
+	flagSet.Var(&destinationPropertyFlags, "destination-property", "additional property of destination")
+	recursiveFlag := flagSet.Bool("recursive", false, "look for applications recursively")
+	depthFlag := flagSet.Int("depth", defaultSearchDepth, "maximum depth of recursive search")
 	flagSet.Parse(args)
 
 	// Normalize arguments and aliases
@@ -95,6 +192,87 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 		serviceName = *nameFlag
 	}
 	log.Tracef("Service name: %v\n", serviceName)
+	log.Tracef("Dry run flag: %v\n", *dryRunFlag)
+	log.Tracef("Incremental flag: %v\n", *incrementalFlag)
+	log.Tracef("Snapshot flag: %v\n", *snapshotFlag)
+	log.Tracef("Security descriptor: %v\n", *securityDescriptorFlag)
+	log.Tracef("No update flag: %v\n", *noUpdateFlag)
+	log.Tracef("Allow shadowing flag: %v\n", *allowShadowingFlag)
+	log.Tracef("Destination name: %v, description: %v, properties: %v\n", *destinationNameFlag, *destinationDescriptionFlag, destinationPropertyFlags)
+	log.Tracef("Set version: %v, version suffix: %v, set service: %v\n", *setVersionFlag, *versionSuffixFlag, *setServiceFlag)
+	log.Tracef("Vars file: %v, vars env flag: %v, vars globs: %v\n", *varsFileFlag, *varsEnvFlag, varsGlobFlags)
+	log.Tracef("Verify flag: %v\n", *verifyFlag)
+	log.Tracef("Recursive flag: %v, depth: %d\n", *recursiveFlag, *depthFlag)
+
+	// Validate depth of recursive search
+	if *depthFlag < 0 {
+		ui.Failed("Depth of recursive search should not be negative")
+		return Failure
+	}
+
+	// Validate that destination options are used only with destinations
+	if (*destinationNameFlag != "" || *destinationDescriptionFlag != "" || len(destinationPropertyFlags) > 0) &&
+		!destination && destinationInstance == "" && businessService == "" {
+		ui.Failed("Destination name, description and properties can only be used together with --destination, --destination-instance or --service options")
+		return Failure
+	}
+	destinationProperties, err := parseDestinationProperties(destinationPropertyFlags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Validate that security descriptor is used only with destinations
+	if *securityDescriptorFlag != "" && !destination && destinationInstance == "" {
+		ui.Failed("Security descriptor can only be used together with --destination or --destination-instance options")
+		return Failure
+	}
+
+	// Variables substituted for placeholders
+	if len(varsGlobFlags) > 0 && *varsFileFlag == "" && !*varsEnvFlag {
+		ui.Failed("Vars glob patterns can only be used together with --vars-file or --vars-env options")
+		return Failure
+	}
+	transform := &appTransformer{
+		Version:       *setVersionFlag,
+		VersionSuffix: *versionSuffixFlag,
+		Service:       *setServiceFlag,
+		VarsEnv:       *varsEnvFlag,
+	}
+	if *varsFileFlag != "" {
+		transform.Vars, err = loadVariables(*varsFileFlag)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+	}
+	transform.VarsFiles, err = parseVarsGlobs(varsGlobFlags)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Push options
+	options := PushOptions{
+		Redeploy:               redeploy,
+		Destination:            destination,
+		BusinessService:        businessService,
+		DestinationInstance:    destinationInstance,
+		Runtime:                runtime,
+		DryRun:                 *dryRunFlag,
+		Recursive:              *recursiveFlag,
+		Depth:                  *depthFlag,
+		Incremental:            *incrementalFlag,
+		Snapshot:               *snapshotFlag,
+		SecurityDescriptor:     *securityDescriptorFlag,
+		NoUpdate:               *noUpdateFlag,
+		DestinationName:        *destinationNameFlag,
+		DestinationDescription: *destinationDescriptionFlag,
+		DestinationProperties:  destinationProperties,
+		AllowShadowing:         *allowShadowingFlag,
+		Transform:              transform,
+		Verify:                 *verifyFlag,
+	}
 
 	// Get current working directory
 	cwd, err := os.Getwd()
@@ -103,15 +281,37 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 		return Failure
 	}
 
+	// Read project configuration
+	config, err := loadPushConfig(cwd)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	options.AppHostName = config.AppHostName
+	if *appHostNameFlag != "" {
+		options.AppHostName = *appHostNameFlag
+	}
+	log.Tracef("App-host name: %v\n", options.AppHostName)
+	if *appHostParamsFlag != "" {
+		options.AppHostParams, err = parseJSONParameter(*appHostParamsFlag)
+	} else {
+		options.AppHostParams, err = config.GetAppHostParams()
+	}
+	if err != nil {
+		ui.Failed("Could not parse app-host parameters: %s", err.Error())
+		return Failure
+	}
+	log.Tracef("App-host parameters: %+v\n", options.AppHostParams)
+
 	// No arguments passed
 	if len(args) == 0 {
 		log.Tracef("No arguments passed. Looking for application directories\n")
-		dirs, err := findAppDirectories(cwd, businessService != "")
+		dirs, err := findAppDirectories(cwd, businessService != "", options)
 		if err != nil {
 			ui.Failed("%+v", err)
 			return Failure
 		}
-		return c.PushHTML5Applications(dirs, "", redeploy, destination, businessService, destinationInstance, runtime)
+		return c.PushHTML5Applications(dirs, "", options)
 	}
 
 	// Check if passed argument is app-host-id or application
@@ -143,6 +343,24 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 		return Failure
 	}
 
+	// Validate that app-host-id and new app-host name or parameters are not passed together
+	if match && (*appHostNameFlag != "" || *appHostParamsFlag != "") {
+		ui.Failed("App-host-id argument and new app-host name or parameters are mutually exclusive. Please use one of them and remove another.")
+		return Failure
+	}
+
+	// Validate that app-host name and new app-host name or parameters are not passed together
+	if serviceName != "" && (*appHostNameFlag != "" || *appHostParamsFlag != "") {
+		ui.Failed("Name of app-host and new app-host name or parameters are mutually exclusive. Please use one of them and remove another.")
+		return Failure
+	}
+
+	// Validate that redeploy and new app-host name or parameters are not passed together
+	if redeploy && (*appHostNameFlag != "" || *appHostParamsFlag != "") {
+		ui.Failed("Redeploy flag and new app-host name or parameters are mutually exclusive. Please use one of them and remove another.")
+		return Failure
+	}
+
 	// Validate that business service and destination are not passed together
 	if destination && businessService != "" {
 		ui.Failed("Destination flag and business service instance name argument are mutually exclusive. Please use one of them and remove another.")
@@ -174,15 +392,15 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 		log.Tracef("Resolved app-host-id is '%s'\n", serviceInstance.GUID)
 		if flagSet.NArg() == 0 {
 			// Only app-host name is provided
-			dirs, err := findAppDirectories(cwd, true)
+			dirs, err := findAppDirectories(cwd, true, options)
 			if err != nil {
 				ui.Failed("%+v", err)
 				return Failure
 			}
-			return c.PushHTML5Applications(dirs, serviceInstance.GUID, redeploy, destination, businessService, destinationInstance, runtime)
+			return c.PushHTML5Applications(dirs, serviceInstance.GUID, options)
 		}
 		// Both application paths and app-host name are provided
-		return c.PushHTML5Applications(flagSet.Args(), serviceInstance.GUID, redeploy, destination, businessService, destinationInstance, runtime)
+		return c.PushHTML5Applications(flagSet.Args(), serviceInstance.GUID, options)
 	}
 
 	// Last argument is app-host-id
@@ -191,38 +409,39 @@ func (c *PushCommand) Execute(args []string) ExecutionStatus {
 		// Last argument is app-host-id
 		if flagSet.NArg() == 1 {
 			// Only app-host-id is provided
-			dirs, err := findAppDirectories(cwd, businessService != "")
+			dirs, err := findAppDirectories(cwd, businessService != "", options)
 			if err != nil {
 				ui.Failed("%+v", err)
 				return Failure
 			}
-			return c.PushHTML5Applications(dirs, flagSet.Args()[0], redeploy, destination, businessService, destinationInstance, runtime)
+			return c.PushHTML5Applications(dirs, flagSet.Args()[0], options)
 		}
 		// Both application paths and app-host-id are provided
-		return c.PushHTML5Applications(flagSet.Args()[:flagSet.NArg()-1], args[len(args)-1], redeploy, destination, businessService, destinationInstance, runtime)
+		return c.PushHTML5Applications(flagSet.Args()[:flagSet.NArg()-1], args[len(args)-1], options)
 	}
 
 	// No app directories passed
 	if flagSet.NArg() == 0 {
-		dirs, err := findAppDirectories(cwd, businessService != "")
+		dirs, err := findAppDirectories(cwd, businessService != "", options)
 		if err != nil {
 			ui.Failed("%+v", err)
 			return Failure
 		}
-		return c.PushHTML5Applications(dirs, "", redeploy, destination, businessService, destinationInstance, runtime)
+		return c.PushHTML5Applications(dirs, "", options)
 	}
 
 	// Last argument is application name
-	return c.PushHTML5Applications(flagSet.Args(), "", redeploy, destination, businessService, destinationInstance, runtime)
+	return c.PushHTML5Applications(flagSet.Args(), "", options)
 }
 
 // PushHTML5Applications push HTML5 applications to app-host-id
-func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID string, redeploy bool, destination bool, businessServiceName string, destinationInstance string, runtime string) ExecutionStatus {
+func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID string, options PushOptions) ExecutionStatus {
 	var err error
-	var zipFiles []string
 	var destinationMessage = " "
 	var actionMessage = "Pushing"
 	var html5Context HTML5Context
+	var pushPlan = PushPlan{}
+	var skippedApps = make([]string, 0)
 
 	// Get context
 	log.Tracef("Getting context (org/space/username)\n")
@@ -233,19 +452,24 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 	}
 
 	// Update message if destination need to be created
-	if destination {
+	if options.Destination {
 		destinationMessage = " and creating subaccount level destination "
 	}
 
-	if destinationInstance != "" {
+	if options.DestinationInstance != "" {
 		destinationMessage = " and creating service instance level destination "
 	}
 
 	// Update message according to the action (deploy/redeploy)
-	if redeploy || appHostGUID != "" {
+	if options.Redeploy || appHostGUID != "" {
 		actionMessage = "Redeploying"
 	}
 
+	// Update message if only deployment plan is requested
+	if options.DryRun {
+		actionMessage = "Planning deployment of"
+	}
+
 	ui.Say("%s HTML5 applications%sin org %s / space %s as %s...",
 		actionMessage,
 		destinationMessage,
@@ -253,11 +477,26 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 		terminal.EntityNameColor(context.Space),
 		terminal.EntityNameColor(context.Username))
 
-	// Check appPaths are application directories
+	// Check appPaths are application directories, application archives
+	// or directories with application archives
 	dirs := make([]string, 0)
-	for _, dir := range appPaths {
-		if isAppDirectory(dir) {
-			dirs = append(dirs, dir)
+	for _, dir := range expandAppPaths(appPaths) {
+		var appDirs []string
+		if options.Recursive && !isAppDirectory(dir) {
+			appDirs = findAppDirectoriesRecursive(dir, options.Depth)
+		}
+		if isAppDirectory(dir) || isAppArchive(dir) {
+			dirs = appendUniquePath(dirs, dir)
+		} else if archives := findAppArchives(dir); len(archives) > 0 {
+			log.Tracef("Directory '%s' contains application archives: %+v\n", dir, archives)
+			for _, archive := range archives {
+				dirs = appendUniquePath(dirs, archive)
+			}
+		} else if len(appDirs) > 0 {
+			log.Tracef("Directory '%s' contains applications: %+v\n", dir, appDirs)
+			for _, appDir := range appDirs {
+				dirs = appendUniquePath(dirs, appDir)
+			}
 		} else {
 			ui.Say("%s%s%s",
 				terminal.AdvisoryColor("WARNING: Directory '"),
@@ -265,32 +504,53 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 				terminal.AdvisoryColor("' is not an application and will not be pushed!\n"))
 		}
 	}
-	if len(dirs) == 0 && businessServiceName == "" {
-		ui.Failed("Nothing to push. Make sure provided directories contain manifest.json and xs-app.json files")
+	if len(dirs) == 0 && options.BusinessService == "" {
+		ui.Failed("Nothing to push. Make sure provided directories or zip archives contain manifest.json and xs-app.json files")
+		return Failure
+	}
+
+	// Validate placeholders of application files
+	validationProblems := make([]ValidationProblem, 0)
+	for _, dir := range dirs {
+		validationProblems = append(validationProblems, options.Transform.Validate(dir)...)
+	}
+	if len(validationProblems) > 0 {
+		printValidationProblems(validationProblems)
+		ui.Failed("Found %d unresolved placeholder(s) in application files. Define their values with --vars-file or --vars-env options", len(validationProblems))
 		return Failure
 	}
 
+	// Validate application descriptors
+	for _, dir := range dirs {
+		validationProblems = append(validationProblems, validateHTML5Application(dir, options.Transform)...)
+	}
+	validationErrors, validationWarnings := splitValidationProblems(validationProblems)
+	if len(validationErrors) > 0 {
+		printValidationProblems(validationProblems)
+		ui.Failed("Found %d problem(s) in application descriptors. Use 'cf html5-validate' command to check them", len(validationErrors))
+		return Failure
+	}
+	if len(validationWarnings) > 0 {
+		printValidationProblems(validationWarnings)
+	}
+
 	appNames := make([]string, 0)
 	appVersions := make([]string, 0)
 	sapCloudService := ""
 	serviceScopes := make([]string, 0)
+	appIDs := make([]string, 0)
+	appIDDirs := make(map[string][]string)
 	if len(dirs) > 0 {
 		// Collect application names
 		for _, dir := range dirs {
 			// Get HTML5 application manifest
 			fileName := dir + slash + "manifest.json"
 			log.Tracef("Reading %s\n", fileName)
-			file, err := os.Open(fileName)
+			fileContents, err := readTransformedAppFile(dir, "manifest.json", options.Transform)
 			if err != nil {
 				ui.Failed(err.Error())
 				return Failure
 			}
-			fileContents, err := ioutil.ReadAll(file)
-			if err != nil {
-				ui.Failed(err.Error())
-				return Failure
-			}
-			file.Close()
 
 			// Read application name from manifest
 			log.Tracef("Extracting application name from: %s\n", string(fileContents))
@@ -304,10 +564,13 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 				ui.Failed("Manifest file %s does not define application name (sap.app/id)", fileName)
 				return Failure
 			}
+			if _, ok := appIDDirs[manifest.SapApp.ID]; !ok {
+				appIDs = append(appIDs, manifest.SapApp.ID)
+			}
+			appIDDirs[manifest.SapApp.ID] = append(appIDDirs[manifest.SapApp.ID], dir)
 
 			// Normalize application name
-			appName := strings.Replace(manifest.SapApp.ID, ".", "", -1)
-			appName = strings.Replace(appName, "-", "", -1)
+			appName := normalizeAppName(manifest.SapApp.ID)
 			if appName == "" {
 				ui.Failed("Manifest file %s defined invalid application name (sap.app/id = '%s')", fileName, manifest.SapApp.ID)
 				return Failure
@@ -320,9 +583,15 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 				return Failure
 			}
 			appVersions = append(appVersions, manifest.SapApp.ApplicationVersion.Version)
+			pushPlan.Apps = append(pushPlan.Apps, PushPlanApp{
+				Name:    appName,
+				Version: manifest.SapApp.ApplicationVersion.Version,
+				Service: manifest.SapCloud.Service,
+				Path:    dir,
+			})
 
 			// Business Service
-			if (destination || destinationInstance != "") && (manifest.SapCloud.Service != "") && (sapCloudService != "") && (manifest.SapCloud.Service != sapCloudService) {
+			if (options.Destination || options.DestinationInstance != "") && (manifest.SapCloud.Service != "") && (sapCloudService != "") && (manifest.SapCloud.Service != sapCloudService) {
 				ui.Failed(
 					"Manifest file %s defines business service name (sap.cloud/service) '%s', which differs from '%s'. "+
 						"Deployment of multiple applications with different service names is not compatible with "+
@@ -336,21 +605,15 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 
 			// If destination need to be created, collect scopes
 			// for which role templates need to be created
-			if destination || destinationInstance != "" {
+			if options.Destination || options.DestinationInstance != "" {
 				// Get HTML5 application application descriptor
 				fileName := dir + slash + "xs-app.json"
 				log.Tracef("Reading %s\n", fileName)
-				file, err := os.Open(fileName)
-				if err != nil {
-					ui.Failed(err.Error())
-					return Failure
-				}
-				fileContents, err := ioutil.ReadAll(file)
+				fileContents, err := readTransformedAppFile(dir, "xs-app.json", options.Transform)
 				if err != nil {
 					ui.Failed("Failed to read application descriptor '%s': %s\n", fileName, err.Error())
 					return Failure
 				}
-				file.Close()
 
 				// Parse application descriptor
 				var applicationDescriptor models.HTML5AppDescriptor
@@ -382,8 +645,31 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 			}
 		}
 
+		// Check that each application is pushed only once
+		duplicates := make([]string, 0)
+		for _, appID := range appIDs {
+			if len(appIDDirs[appID]) > 1 {
+				duplicates = append(duplicates, fmt.Sprintf("'%s' is defined in %s", appID, strings.Join(appIDDirs[appID], ", ")))
+			}
+		}
+		if len(duplicates) > 0 {
+			ui.Failed("Multiple folders define the same application name (sap.app/id):\n%s", strings.Join(duplicates, "\n"))
+			return Failure
+		}
+
+		// Check that different applications do not get the same name
+		collisions := findBatchCollisions(appIDs, appIDDirs)
+		if len(collisions) > 0 && !options.AllowShadowing {
+			ui.Failed("Multiple applications get the same name after removing dots and dashes from sap.app/id:\n%s\n"+
+				"Use --allow-shadowing option to push them anyway", strings.Join(collisions, "\n"))
+			return Failure
+		}
+		for _, collision := range collisions {
+			ui.Warn("Application name collision: %s", collision)
+		}
+
 		// Find existing app-host
-		if appHostGUID == "" && redeploy {
+		if appHostGUID == "" && options.Redeploy {
 
 			// Get HTML5 context
 			if html5Context.ServiceName == "" {
@@ -447,6 +733,7 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 								return Failure
 							}
 							appHostGUID = serviceInstance.GUID
+							pushPlan.AppHostName = serviceInstance.Name
 							break ServiceInstanceLoop
 						}
 					}
@@ -460,8 +747,30 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 			}
 		}
 
+		// Check that applications do not shadow applications of other app-hosts
+		if html5Context.ServiceName == "" {
+			html5Context, err = c.GetHTML5Context(context)
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
+		}
+		collisions, err = c.findDeployedCollisions(html5Context, context.SpaceID, appHostGUID, appNames, dirs)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		if len(collisions) > 0 && !options.AllowShadowing {
+			ui.Failed("Applications with the same names are deployed to other app-host service instances in the space:\n%s\n"+
+				"Use --allow-shadowing option to push them anyway", strings.Join(collisions, "\n"))
+			return Failure
+		}
+		for _, collision := range collisions {
+			ui.Warn("Application name collision: %s", collision)
+		}
+
 		// Create new app-host
-		if appHostGUID == "" && !redeploy {
+		if appHostGUID == "" && !options.Redeploy {
 
 			// Get name of html5-apps-repo service
 			serviceName := os.Getenv("HTML5_SERVICE_NAME")
@@ -522,123 +831,84 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 			}
 
 			// Create service instance
-			log.Tracef("Creating service instance for plan %+v\n", *servicePlan)
 			serviceInstanceName := strings.Replace(sapCloudService, ".", "", -1)
 			if len(serviceInstanceName) > 0 {
 				serviceInstanceName = serviceInstanceName + "-"
 			}
-			serviceInstance, err := clients.CreateServiceInstance(c.CliConnection, spaceGUID, *servicePlan, nil, serviceInstanceName)
-			if err != nil {
-				ui.Failed("Could not create service instance for %s app-host plan: %+v", serviceName, err)
-				return Failure
-			}
-			appHostGUID = serviceInstance.GUID
-		}
-
-		// Create service key for DT
-		log.Tracef("Creating service key for app-host-id '%s'\n", appHostGUID)
-		serviceKey, err := clients.CreateServiceKey(c.CliConnection, appHostGUID, nil)
-		if err != nil {
-			ui.Failed("Could not create service key for service instance with id '%s' : %+v", appHostGUID, err)
-			return Failure
-		}
-
-		// Obtain access token
-		log.Tracef("Obtaining access token for service key '%s'\n", serviceKey.Name)
-		token, err := clients.GetToken(serviceKey.Credentials)
-		if err != nil {
-			ui.Failed("Could not obtain access token for service key '': %+v", serviceKey.Name, err)
-			return Failure
-		}
-
-		// Zip applications
-		tmp := os.TempDir()
-		if strings.LastIndex(tmp, slash) != len(tmp)-1 {
-			tmp = tmp + slash
-		}
-		zipFiles = make([]string, 0)
-		for idx, appPath := range dirs {
-			log.Tracef("Zipping the directory: '%s'\n", appPath)
-
-			var appPathFiles = make([]string, 0)
-			files, err := ioutil.ReadDir(appPath)
-			if err != nil {
-				ui.Failed("Could not read contents of application directory '%s' : %+v", appPath, err)
-				return Failure
-			}
-			for _, file := range files {
-				log.Tracef("Adding file to archive: '%s'\n", appPath+slash+file.Name())
-				appPathFiles = append(appPathFiles, appPath+slash+file.Name())
-			}
-
-			zipPath := tmp + appNames[idx] + "-" + appVersions[idx] + ".zip"
-			err = zipit(appPathFiles, zipPath)
-			if err != nil {
-				ui.Failed("Could not zip application directory '%s' : %+v", zipPath, err)
-				return Failure
+			if options.AppHostName != "" {
+				serviceInstanceName = options.AppHostName
 			}
-			zipFiles = append(zipFiles, zipPath)
-		}
-
-		// Upload zips
-		err = clients.UploadAppHost(*serviceKey.Credentials.URI, zipFiles, token)
-		if err != nil {
-			ui.Failed("Could not upload applications to app-host-id '%s' : %+v", appHostGUID, err)
-			return Failure
-		}
-
-		// Delete temporarry zip files
-		for _, zipFile := range zipFiles {
-			_, err = os.Stat(zipFile)
-			if err == nil {
-				log.Tracef("Deleting temporarry zip file: '%s'\n", zipFile)
-				err = os.Remove(zipFile)
+			if options.DryRun {
+				pushPlan.CreateAppHost = true
+				pushPlan.AppHostName = serviceInstanceName
+				pushPlan.AppHostParams = options.AppHostParams
+				if options.AppHostName == "" {
+					pushPlan.AppHostName = serviceInstanceName + servicePlan.Name + "-<timestamp>"
+				}
+			} else {
+				log.Tracef("Creating service instance for plan %+v\n", *servicePlan)
+				serviceInstance, err := clients.CreateServiceInstance(c.CliConnection, spaceGUID, *servicePlan, options.AppHostParams, serviceInstanceName, nil)
 				if err != nil {
-					ui.Failed("Could not delete zip file '%s' : %+v", zipFile, err)
+					ui.Failed("Could not create service instance for %s app-host plan: %+v", serviceName, err)
 					return Failure
 				}
-			} else {
-				log.Tracef("Temporarry zip file does not exist and will not be removed: '%s'\n", zipFile)
+				appHostGUID = serviceInstance.GUID
 			}
 		}
 
-		// Delete temporarry service keys
-		log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
-		err = clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
-		if err != nil {
-			ui.Failed("Could not delete service key '%s' : %+v", serviceKey.Name, err)
-			return Failure
+		// Upload applications
+		pushPlan.AppHostGUID = appHostGUID
+		pushPlan.Snapshot = appHostGUID != "" && options.Snapshot
+		appHostServiceInstance := pushPlan.AppHostName
+		if appHostServiceInstance == "" {
+			appHostServiceInstance = appHostGUID
+		}
+		pushPlan.ServiceKeys = append(pushPlan.ServiceKeys, PushPlanServiceKey{
+			ServiceInstance: appHostServiceInstance,
+			Temporary:       true,
+		})
+		if !options.DryRun {
+			skippedApps, err = c.UploadHTML5Applications(context, &html5Context, appHostGUID, dirs, appNames, appVersions, options)
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
 		}
+	}
 
+	// App-host used by destinations (not known yet, if it is only planned to be created)
+	destinationAppHostGUID := appHostGUID
+	if appHostGUID == "" && pushPlan.CreateAppHost {
+		destinationAppHostGUID = "<app-host-id>"
 	}
 
 	// Create destination configuration
-	if destination || (destinationInstance != "") {
+	if options.Destination || (options.DestinationInstance != "") {
 		// Create xs-uaa service instance with
 		// role templates for each scope required
 		// by applications
 		log.Tracef("Starting to build destination configuration\n")
-		securityDescriptorScopes := make([]models.UAASecurityDescriptorScope, len(serviceScopes))
-		securityDescriptorRoleTemplates := make([]models.UAASecurityDescriptorRoleTemplate, len(serviceScopes))
-		for idx, scope := range serviceScopes {
-			scopeNameWithoutPrefix := strings.Split(scope, ".")[1]
-			securityDescriptorScopes[idx] = models.UAASecurityDescriptorScope{
-				Name:        &scope,
-				Description: &scopeNameWithoutPrefix,
-			}
-			securityDescriptorRoleTemplates[idx] = models.UAASecurityDescriptorRoleTemplate{
-				Name:            &scopeNameWithoutPrefix,
-				Description:     &scope,
-				ScopeReferences: []string{scope},
+		// Security descriptor provided by user
+		var userSecurityDescriptor map[string]interface{}
+		if options.SecurityDescriptor != "" {
+			userSecurityDescriptor, err = loadSecurityDescriptor(options.SecurityDescriptor)
+		} else {
+			var fileName string
+			userSecurityDescriptor, fileName, err = findSecurityDescriptor(dirs)
+			if userSecurityDescriptor != nil {
+				ui.Say("Using security descriptor %s", terminal.EntityNameColor(fileName))
 			}
 		}
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
 		// Define security descriptor
-		securityDescriptor := models.UAASecurityDescriptor{
-			XSAPPNAME:              "app-host-" + appHostGUID,
-			ForeignScopeReferences: []string{"uaa.user"},
-			Scopes:                 securityDescriptorScopes,
-			RoleTemplates:          securityDescriptorRoleTemplates,
+		xsappname := "app-host-" + appHostGUID
+		if appHostGUID == "" && options.DryRun {
+			xsappname = "app-host-<app-host-id>"
 		}
+		securityDescriptor := buildSecurityDescriptor(userSecurityDescriptor, xsappname, serviceScopes)
 		var xsuaaServicePlan *models.CFServicePlan
 		// Get HTML5 context if needed
 		if html5Context.ServiceName == "" {
@@ -676,95 +946,180 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 			ui.Failed("Could not marshal security descriptor: %+v", err)
 			return Failure
 		}
-		log.Tracef("Creating service instance of 'xsuaa' service '%s' plan with parameters: %s\n", xsuaaServicePlan.Name, string(securityDescriptorJSON))
-		xsuaaServiceInstance, err := clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *xsuaaServicePlan, &securityDescriptor, strings.Replace(sapCloudService, ".", "", -1)+"-")
-		if err != nil {
-			ui.Failed("Could not create XSUAA service instance : %+v", err)
-			return Failure
-		}
-		// XSUAA service key
-		log.Tracef("Creating service key of 'xsuaa' service '%s' plan: %+v\n", xsuaaServicePlan.Name, xsuaaServiceInstance)
-		xsuaaServiceInstanceKey, err := clients.CreateServiceKey(c.CliConnection, xsuaaServiceInstance.GUID, nil)
-		if err != nil {
-			ui.Failed("Could not create XSUAA service key : %+v", err)
-			return Failure
-		}
-		if xsuaaServiceInstanceKey.Credentials.URI == nil {
-			uri := html5Context.GetRuntimeURL(runtime)
-			xsuaaServiceInstanceKey.Credentials.URI = &uri
-		}
-		// Credentials for destination configuration
-		log.Tracef("XSUAA service key credentials: %+v\n", log.Sensitive{Data: xsuaaServiceInstanceKey.Credentials})
-		credentials := models.CFCredentials{
-			URI:             xsuaaServiceInstanceKey.Credentials.URI,
-			UAA:             xsuaaServiceInstanceKey.Credentials.UAA,
-			SapCloudService: &sapCloudService,
-			HTML5AppsRepo: &models.HTML5AppsRepo{
-				AppHostID: appHostGUID,
-			},
+		// Look for xsuaa service instance created by previous push for the same app-host
+		var xsuaaServiceInstance *models.CFServiceInstance
+		var xsuaaServiceInstanceKey *models.CFServiceKey
+		if appHostGUID != "" {
+			log.Tracef("Looking for 'xsuaa' service instance with label %s=%s\n", xsuaaAppHostLabel, appHostGUID)
+			xsuaaServiceInstances, err := clients.GetServiceInstancesByLabel(c.CliConnection, context.SpaceID, *xsuaaServicePlan, xsuaaAppHostLabel, appHostGUID)
+			if err != nil {
+				ui.Failed("Could not get XSUAA service instances : %+v", err)
+				return Failure
+			}
+			if len(xsuaaServiceInstances) > 0 {
+				xsuaaServiceInstance = &xsuaaServiceInstances[0]
+				log.Tracef("Found 'xsuaa' service instance: %+v\n", *xsuaaServiceInstance)
+				xsuaaServiceKeys, err := clients.GetServiceKeys(c.CliConnection, xsuaaServiceInstance.GUID)
+				if err != nil {
+					ui.Failed("Could not get service keys of XSUAA service instance '%s' : %+v", xsuaaServiceInstance.Name, err)
+					return Failure
+				}
+				if len(xsuaaServiceKeys) > 0 {
+					xsuaaServiceInstanceKey = &xsuaaServiceKeys[0]
+				}
+			}
 		}
+		if options.DryRun {
+			xsuaaServiceInstanceName := strings.Replace(sapCloudService, ".", "", -1) + "-" + xsuaaServicePlan.Name + "-<timestamp>"
+			if xsuaaServiceInstance != nil {
+				xsuaaServiceInstanceName = xsuaaServiceInstance.Name
+				pushPlan.UpdateXSUAAServiceInstance = true
+			}
+			pushPlan.XSUAAServiceInstanceName = xsuaaServiceInstanceName
+			pushPlan.SecurityDescriptor = securityDescriptor
+			// Credentials for destination configuration are known only for existing service key
+			uri := html5Context.GetRuntimeURL(options.Runtime)
+			credentials := models.CFCredentials{
+				URI:             &uri,
+				SapCloudService: &sapCloudService,
+				HTML5AppsRepo: &models.HTML5AppsRepo{
+					AppHostID: destinationAppHostGUID,
+				},
+			}
+			if xsuaaServiceInstanceKey == nil {
+				pushPlan.ServiceKeys = append(pushPlan.ServiceKeys, PushPlanServiceKey{ServiceInstance: xsuaaServiceInstanceName})
+			} else {
+				if xsuaaServiceInstanceKey.Credentials.URI != nil {
+					credentials.URI = xsuaaServiceInstanceKey.Credentials.URI
+				}
+				credentials.UAA = xsuaaServiceInstanceKey.Credentials.UAA
+			}
+			destination, err := c.PlanHTML5Destination(context, credentials, xsuaaServiceInstanceName, xsuaaServiceInstanceKey == nil, options)
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
+			pushPlan.Destinations = append(pushPlan.Destinations, destination)
+		} else {
+			if xsuaaServiceInstance != nil {
+				log.Tracef("Updating service instance '%s' of 'xsuaa' service with parameters: %s\n", xsuaaServiceInstance.Name, string(securityDescriptorJSON))
+				err = clients.UpdateServiceInstance(c.CliConnection, xsuaaServiceInstance.GUID, securityDescriptor)
+				if err != nil {
+					ui.Failed("Could not update XSUAA service instance '%s' : %+v", xsuaaServiceInstance.Name, err)
+					return Failure
+				}
+			} else {
+				log.Tracef("Creating service instance of 'xsuaa' service '%s' plan with parameters: %s\n", xsuaaServicePlan.Name, string(securityDescriptorJSON))
+				xsuaaServiceInstance, err = clients.CreateServiceInstance(c.CliConnection, context.SpaceID, *xsuaaServicePlan, securityDescriptor,
+					strings.Replace(sapCloudService, ".", "", -1)+"-", map[string]string{xsuaaAppHostLabel: appHostGUID})
+				if err != nil {
+					ui.Failed("Could not create XSUAA service instance : %+v", err)
+					return Failure
+				}
+			}
+			// XSUAA service key
+			if xsuaaServiceInstanceKey == nil {
+				log.Tracef("Creating service key of 'xsuaa' service '%s' plan: %+v\n", xsuaaServicePlan.Name, xsuaaServiceInstance)
+				xsuaaServiceInstanceKey, err = clients.CreateServiceKey(c.CliConnection, xsuaaServiceInstance.GUID, nil)
+				if err != nil {
+					ui.Failed("Could not create XSUAA service key : %+v", err)
+					return Failure
+				}
+			} else {
+				log.Tracef("Reusing service key '%s' of 'xsuaa' service instance '%s'\n", xsuaaServiceInstanceKey.Name, xsuaaServiceInstance.Name)
+			}
+			if xsuaaServiceInstanceKey.Credentials.URI == nil {
+				uri := html5Context.GetRuntimeURL(options.Runtime)
+				xsuaaServiceInstanceKey.Credentials.URI = &uri
+			}
+			// Credentials for destination configuration
+			log.Tracef("XSUAA service key credentials: %+v\n", log.Sensitive{Data: xsuaaServiceInstanceKey.Credentials})
+			credentials := models.CFCredentials{
+				URI:             xsuaaServiceInstanceKey.Credentials.URI,
+				UAA:             xsuaaServiceInstanceKey.Credentials.UAA,
+				SapCloudService: &sapCloudService,
+				HTML5AppsRepo: &models.HTML5AppsRepo{
+					AppHostID: appHostGUID,
+				},
+			}
 
-		// Create destination configuration
-		err = c.CreateHTML5Destination(context, credentials, destinationInstance)
-		if err != nil {
-			ui.Failed("Could not create destination configuration")
-			return Failure
+			// Create destination configuration
+			err = c.CreateHTML5Destination(context, credentials, options)
+			if err != nil {
+				ui.Failed("Could not create destination configuration")
+				return Failure
+			}
 		}
 	}
 
 	// Create destination configuration with business service credentials
-	if businessServiceName != "" {
+	if options.BusinessService != "" {
 		log.Tracef("Creating destination with business service credentials\n")
 		// Get business service instance by name
-		log.Tracef("Looking up for service instance with name '%s'\n", businessServiceName)
+		log.Tracef("Looking up for service instance with name '%s'\n", options.BusinessService)
 		businessServiceInstance, err := clients.GetServiceInstanceByName(
-			c.CliConnection, context.SpaceID, businessServiceName)
+			c.CliConnection, context.SpaceID, options.BusinessService)
 		if err != nil {
-			ui.Failed("Could not get service instance '%s' by name: %s", businessServiceName, err.Error())
+			ui.Failed("Could not get service instance '%s' by name: %s", options.BusinessService, err.Error())
 			return Failure
 		}
-		log.Tracef("Service instance with name '%s' found: %+v\n", businessServiceName, businessServiceInstance)
+		log.Tracef("Service instance with name '%s' found: %+v\n", options.BusinessService, businessServiceInstance)
 		// Get business service instance key
-		log.Tracef("Looking up for existing service keys of service '%s'\n", businessServiceName)
+		log.Tracef("Looking up for existing service keys of service '%s'\n", options.BusinessService)
 		businessServiceKeys, err := clients.GetServiceKeys(c.CliConnection, businessServiceInstance.GUID)
 		if err != nil {
-			ui.Failed("Could not get service instance keys of service '%s': %s", businessServiceName, err.Error())
+			ui.Failed("Could not get service instance keys of service '%s': %s", options.BusinessService, err.Error())
 			return Failure
 		}
 		// Create business service instance key if needed
-		if len(businessServiceKeys) == 0 {
-			log.Tracef("No existing service keys for service instance '%s' found, creatng new one\n", businessServiceName)
+		if len(businessServiceKeys) == 0 && options.DryRun {
+			pushPlan.ServiceKeys = append(pushPlan.ServiceKeys, PushPlanServiceKey{ServiceInstance: options.BusinessService})
+		} else if len(businessServiceKeys) == 0 {
+			log.Tracef("No existing service keys for service instance '%s' found, creatng new one\n", options.BusinessService)
 			businessServiceKey, err := clients.CreateServiceKey(c.CliConnection, businessServiceInstance.GUID, nil)
 			if err != nil {
-				ui.Failed("Could not create service instance key for service '%s': %s", businessServiceName, err.Error())
+				ui.Failed("Could not create service instance key for service '%s': %s", options.BusinessService, err.Error())
 				return Failure
 			}
 			businessServiceKeys = append(businessServiceKeys, *businessServiceKey)
 		} else {
-			log.Tracef("Existing service keys for service instance '%s' found (%d)\n", businessServiceName, len(businessServiceKeys))
+			log.Tracef("Existing service keys for service instance '%s' found (%d)\n", options.BusinessService, len(businessServiceKeys))
+		}
+		// Extract business service credentials (not known, if service key is only planned)
+		var businessServiceCredentilas models.CFCredentials
+		if len(businessServiceKeys) > 0 {
+			businessServiceCredentilas = businessServiceKeys[0].Credentials
+			log.Tracef("Business service credentials from service key: %+v\n", log.Sensitive{Data: businessServiceCredentilas})
 		}
-		// Extract business service credentials
-		businessServiceCredentilas := businessServiceKeys[0].Credentials
-		log.Tracef("Business service credentials from service key: %+v\n", log.Sensitive{Data: businessServiceCredentilas})
 		// Add sap.cloud.service from UI if needed (e.g. xsuaa instance)
 		if businessServiceCredentilas.SapCloudService == nil {
 			log.Tracef("Adding sap.cloud.service to business service credentials: %s\n", sapCloudService)
 			businessServiceCredentilas.SapCloudService = &sapCloudService
 		}
 		// Add app-host-id if needed
-		if appHostGUID != "" {
-			log.Tracef("Adding app-host-id to business service credentials: %s\n", appHostGUID)
+		if destinationAppHostGUID != "" {
+			log.Tracef("Adding app-host-id to business service credentials: %s\n", destinationAppHostGUID)
 			if businessServiceCredentilas.HTML5AppsRepo != nil {
-				businessServiceCredentilas.HTML5AppsRepo.AppHostID = businessServiceCredentilas.HTML5AppsRepo.AppHostID + "," + appHostGUID
+				businessServiceCredentilas.HTML5AppsRepo.AppHostID = businessServiceCredentilas.HTML5AppsRepo.AppHostID + "," + destinationAppHostGUID
 			} else {
-				businessServiceCredentilas.HTML5AppsRepo = &models.HTML5AppsRepo{AppHostID: appHostGUID}
+				businessServiceCredentilas.HTML5AppsRepo = &models.HTML5AppsRepo{AppHostID: destinationAppHostGUID}
 			}
 		}
-		// Create destination with business service credentials
-		err = c.CreateHTML5Destination(context, businessServiceCredentilas, destinationInstance)
-		if err != nil {
-			ui.Failed("Could not create subaccount destination with business service credentials: %s", err.Error())
-			return Failure
+		// Business service destination is only planned
+		if options.DryRun {
+			destination, err := c.PlanHTML5Destination(context, businessServiceCredentilas, options.BusinessService, len(businessServiceKeys) == 0, options)
+			if err != nil {
+				ui.Failed(err.Error())
+				return Failure
+			}
+			pushPlan.Destinations = append(pushPlan.Destinations, destination)
+		} else {
+			// Create destination with business service credentials
+			err = c.CreateHTML5Destination(context, businessServiceCredentilas, options)
+			if err != nil {
+				ui.Failed("Could not create subaccount destination with business service credentials: %s", err.Error())
+				return Failure
+			}
 		}
 	}
 
@@ -777,14 +1132,33 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 		}
 	}
 
+	// Print deployment plan
+	if options.DryRun {
+		return c.PrintPushPlan(pushPlan)
+	}
+
 	ui.Ok()
 	ui.Say("")
 
+	// Print applications skipped in incremental mode
+	if options.Incremental && len(appNames) > 0 {
+		table := ui.Table([]string{"name", "version", "status"})
+		for idx, appName := range appNames {
+			status := "uploaded"
+			if indexOfString(skippedApps, appName+"-"+appVersions[idx]) >= 0 {
+				status = "skipped, identical to deployed version"
+			}
+			table.Add(appName, appVersions[idx], status)
+		}
+		table.Print()
+		ui.Say("")
+	}
+
 	// Print application URLs if needed
-	if destination {
+	if options.Destination {
 		sapCloudServiceName := strings.Replace(sapCloudService, ".", "", -1)
 		for idx, appName := range appNames {
-			ui.Say(html5Context.GetRuntimeURL(runtime) + "/" + sapCloudServiceName + "." + appName + "-" + appVersions[idx] + "/")
+			ui.Say(html5Context.GetRuntimeURL(options.Runtime) + "/" + sapCloudServiceName + "." + appName + "-" + appVersions[idx] + "/")
 		}
 		ui.Say("")
 	}
@@ -792,9 +1166,248 @@ func (c *PushCommand) PushHTML5Applications(appPaths []string, appHostGUID strin
 	return Success
 }
 
+// UploadHTML5Applications zip application directories and upload them to app-host-id.
+// Returns keys of applications, which were not uploaded in incremental mode
+func (c *PushCommand) UploadHTML5Applications(context Context, html5Context *HTML5Context, appHostGUID string,
+	dirs []string, appNames []string, appVersions []string, options PushOptions) ([]string, error) {
+	skippedApps := make([]string, 0)
+
+	// Create service key for DT
+	log.Tracef("Creating service key for app-host-id '%s'\n", appHostGUID)
+	serviceKey, err := clients.CreateServiceKey(c.CliConnection, appHostGUID, nil)
+	if err != nil {
+		return skippedApps, fmt.Errorf("Could not create service key for service instance with id '%s' : %+v", appHostGUID, err)
+	}
+
+	// Clean-up temporarry zip files and service key, if upload fails
+	tmpZipFiles := make([]string, 0)
+	serviceKeyDeleted := false
+	defer func() {
+		for _, zipFile := range tmpZipFiles {
+			if _, err := os.Stat(zipFile); err == nil {
+				log.Tracef("Deleting temporarry zip file: '%s'\n", zipFile)
+				os.Remove(zipFile)
+			}
+		}
+		if serviceKeyDeleted {
+			return
+		}
+		log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+		err := clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+		if err != nil {
+			ui.Warn("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+		}
+	}()
+
+	// Obtain access token
+	log.Tracef("Obtaining access token for service key '%s'\n", serviceKey.Name)
+	token, err := clients.GetToken(serviceKey.Credentials)
+	if err != nil {
+		return skippedApps, fmt.Errorf("Could not obtain access token for service key '%s': %+v", serviceKey.Name, err)
+	}
+
+	// Zip applications
+	tmp := os.TempDir()
+	if strings.LastIndex(tmp, slash) != len(tmp)-1 {
+		tmp = tmp + slash
+	}
+	zipFiles := make([]string, 0)
+	for idx, appPath := range dirs {
+		// Upload application archives as is
+		if isAppArchive(appPath) {
+			if options.Transform.Active() {
+				zipPath := tmp + appNames[idx] + "-" + appVersions[idx] + ".zip"
+				err = transformAppArchive(appPath, zipPath, options.Transform)
+				if err != nil {
+					return skippedApps, err
+				}
+				zipFiles = append(zipFiles, zipPath)
+				tmpZipFiles = append(tmpZipFiles, zipPath)
+				continue
+			}
+			log.Tracef("Using application archive: '%s'\n", appPath)
+			zipFiles = append(zipFiles, appPath)
+			continue
+		}
+
+		ignore, err := loadIgnoreFile(appPath)
+		if err != nil {
+			return skippedApps, fmt.Errorf("Could not read ignore file of application directory '%s' : %+v", appPath, err)
+		}
+
+		zipPath := tmp + appNames[idx] + "-" + appVersions[idx] + ".zip"
+		stats, err := zipAppDirectory(appPath, zipPath, ignore, options.Transform)
+		if err != nil {
+			return skippedApps, err
+		}
+		if ignore != nil {
+			ui.Say("  %s of %s excluded %d files (%s) and %d folders",
+				ignore.FileName, terminal.EntityNameColor(appNames[idx]), stats.ExcludedFiles, getReadableSize(int(stats.ExcludedBytes)), stats.ExcludedDirs)
+		}
+		zipFiles = append(zipFiles, zipPath)
+		tmpZipFiles = append(tmpZipFiles, zipPath)
+	}
+
+	// Skip applications identical to deployed versions
+	verificationProblems := make([]VerificationProblem, 0)
+	uploadZipFiles := zipFiles
+	uploadAppNames := appNames
+	uploadAppVersions := appVersions
+	if options.Incremental {
+		uploadZipFiles, uploadAppNames, uploadAppVersions = make([]string, 0), make([]string, 0), make([]string, 0)
+		if html5Context.ServiceName == "" {
+			*html5Context, err = c.GetHTML5Context(context)
+			if err != nil {
+				return skippedApps, err
+			}
+		}
+		log.Tracef("Getting list of applications for app-host-id '%s'\n", appHostGUID)
+		applications, err := clients.ListApplicationsForAppHost(
+			*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+			html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+			appHostGUID)
+		if err != nil {
+			return skippedApps, fmt.Errorf("Could not get list of applications for app-host-id '%s': %+v", appHostGUID, err)
+		}
+		for idx, zipFile := range zipFiles {
+			appKey := appNames[idx] + "-" + appVersions[idx]
+			unchanged := false
+			for _, application := range applications {
+				if application.ApplicationName == appNames[idx] && application.ApplicationVersion == appVersions[idx] {
+					unchanged, err = isAppUnchanged(*html5Context, appHostGUID, appKey, zipFile)
+					if err != nil {
+						return skippedApps, err
+					}
+					break
+				}
+			}
+			if unchanged {
+				log.Tracef("Application %s is identical to deployed version and will not be uploaded\n", appKey)
+				skippedApps = append(skippedApps, appKey)
+				continue
+			}
+			uploadZipFiles = append(uploadZipFiles, zipFile)
+			uploadAppNames = append(uploadAppNames, appNames[idx])
+			uploadAppVersions = append(uploadAppVersions, appVersions[idx])
+		}
+	}
+
+	if len(uploadZipFiles) > 0 {
+		// Snapshot current content of app-host
+		if options.Snapshot {
+			if html5Context.ServiceName == "" {
+				*html5Context, err = c.GetHTML5Context(context)
+				if err != nil {
+					return skippedApps, err
+				}
+			}
+			snapshot, err := createSnapshot(*html5Context, appHostGUID)
+			if err != nil {
+				return skippedApps, fmt.Errorf("Could not create snapshot of app-host-id '%s' : %+v", appHostGUID, err)
+			}
+			if snapshot != nil {
+				ui.Say("  Snapshot %s of %d applications created. Use 'cf html5-rollback %s' to restore it",
+					terminal.EntityNameColor(snapshot.ID), len(snapshot.Apps), appHostGUID)
+				err = pruneSnapshots(appHostGUID, getSnapshotsKeep())
+				if err != nil {
+					return skippedApps, err
+				}
+			}
+		}
+
+		// Check size limit of app-host
+		err = c.CheckAppHostQuota(context, html5Context, appHostGUID, *serviceKey.Credentials.URI, token, uploadZipFiles, uploadAppNames, uploadAppVersions)
+		if err != nil {
+			return skippedApps, err
+		}
+
+		// Upload zips
+		err = clients.UploadAppHost(*serviceKey.Credentials.URI, uploadZipFiles, token, newUploadProgressReporter())
+		if err != nil {
+			return skippedApps, fmt.Errorf("Could not upload applications to app-host-id '%s' : %+v", appHostGUID, err)
+		}
+
+		// Verify uploaded content
+		if options.Verify {
+			if html5Context.ServiceName == "" {
+				*html5Context, err = c.GetHTML5Context(context)
+				if err != nil {
+					return skippedApps, err
+				}
+			}
+			verifiedFiles := 0
+			for idx, zipFile := range uploadZipFiles {
+				appKey := uploadAppNames[idx] + "-" + uploadAppVersions[idx]
+				problems, files, err := verifyPushedApp(*html5Context, appHostGUID, appKey, zipFile)
+				if err != nil {
+					return skippedApps, fmt.Errorf("Could not verify application %s : %+v", appKey, err)
+				}
+				verificationProblems = append(verificationProblems, problems...)
+				verifiedFiles += files
+			}
+			if len(verificationProblems) == 0 {
+				ui.Say("  Verified %d files of %d applications", verifiedFiles, len(uploadZipFiles))
+			}
+		}
+	} else {
+		log.Tracef("All applications are identical to deployed versions, nothing to upload\n")
+	}
+
+	// Delete temporarry zip files
+	for _, zipFile := range tmpZipFiles {
+		_, err = os.Stat(zipFile)
+		if err == nil {
+			log.Tracef("Deleting temporarry zip file: '%s'\n", zipFile)
+			err = os.Remove(zipFile)
+			if err != nil {
+				return skippedApps, fmt.Errorf("Could not delete zip file '%s' : %+v", zipFile, err)
+			}
+		} else {
+			log.Tracef("Temporarry zip file does not exist and will not be removed: '%s'\n", zipFile)
+		}
+	}
+
+	// Delete temporarry service keys
+	log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+	err = clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+	serviceKeyDeleted = true
+	if err != nil {
+		return skippedApps, fmt.Errorf("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+	}
+
+	// Report verification problems
+	if len(verificationProblems) > 0 {
+		printVerificationProblems(verificationProblems)
+		return skippedApps, fmt.Errorf("Found %d missing or mismatched file(s) in app-host-id '%s' after upload", len(verificationProblems), appHostGUID)
+	}
+
+	return skippedApps, nil
+}
+
+// newUploadProgressReporter returns upload progress callback, which prints
+// number of bytes sent for each application every 25 percent
+func newUploadProgressReporter() clients.UploadProgress {
+	const step = 25
+	reported := make(map[string]int64)
+	return func(zipFile string, sent int64, total int64) {
+		percent := int64(100)
+		if total > 0 {
+			percent = sent * 100 / total
+		}
+		last, ok := reported[zipFile]
+		if ok && percent/step <= last/step {
+			return
+		}
+		reported[zipFile] = percent
+		ui.Say("  %s: %s of %s sent (%d%%)",
+			terminal.EntityNameColor(filepath.Base(zipFile)), getReadableSize(int(sent)), getReadableSize(int(total)), percent)
+	}
+}
+
 // CreateHTML5Destination cretes destination with XSUAA credentials, "sap.cloud.service" and "app-host-id"
-func (c *PushCommand) CreateHTML5Destination(context Context, credentials models.CFCredentials, destinationInstance string) error {
+func (c *PushCommand) CreateHTML5Destination(context Context, credentials models.CFCredentials, options PushOptions) error {
 	var err error
+	var destinationInstance = options.DestinationInstance
 	var destinations models.DestinationListDestinationsResponse
 	var destinationLevel string
 	if destinationInstance == "" {
@@ -833,92 +1446,64 @@ func (c *PushCommand) CreateHTML5Destination(context Context, credentials models
 	log.Tracef("List of %s destinations: %+v\n", destinationLevel, destinations)
 
 	// Look for html5 destination
-	var html5Destination *models.DestinationConfiguration
-	for _, destination := range destinations {
-		if destination.Properties["sap.cloud.service"] == *credentials.SapCloudService {
-			html5Destination = &destination
-			break
-		}
-	}
-	if html5Destination == nil {
-		log.Tracef("Creating new HTML5 destination\n")
+	existingDestination := findHTML5Destination(destinations, *credentials.SapCloudService)
 
-		if credentials.URI == nil {
-			emptyURI := ""
-			credentials.URI = &emptyURI
-		}
-
-		if credentials.HTML5AppsRepo == nil {
-			credentials.HTML5AppsRepo = &models.HTML5AppsRepo{}
-		}
-
-		// Build destination configuration
-		html5Destination = &models.DestinationConfiguration{
-			Name:                strings.Replace(*credentials.SapCloudService, ".", "", -1),
-			Description:         "Business Service Destination",
-			Type:                "HTTP",
-			URL:                 *credentials.URI,
-			Authentication:      "OAuth2ClientCredentials",
-			ProxyType:           "Internet",
-			TokenServiceURL:     credentials.UAA.URL + "/oauth/token",
-			TokenServiceURLType: "Dedicated",
-			ClientID:            credentials.UAA.ClientID,
-			ClientSecret:        credentials.UAA.ClientSecret,
-			Properties: map[string]string{
-				"sap.cloud.service": *credentials.SapCloudService,
-				"xsappname":         credentials.UAA.XSAPPNAME,
-			},
-		}
-
-		// html5-apps-repo
-		if credentials.HTML5AppsRepo.AppHostID != "" {
-			if os.Getenv("HTML5_COMPATIBILITY") == "1.4.3" {
-				html5Destination.Properties["html5-apps-repo.app_host_id"] = credentials.HTML5AppsRepo.AppHostID
-			} else {
-				html5Destination.Properties["html5-apps-repo"] = "{\"app_host_id\":\"" + credentials.HTML5AppsRepo.AppHostID + "\"}"
-			}
-		}
-
-		// Endpoints
-		if credentials.Endpoints != nil {
-			log.Tracef("Destination endpoints: %+v\n", *credentials.Endpoints)
-			if os.Getenv("HTML5_COMPATIBILITY") == "1.4.3" {
-				for endpointKey, endpointValue := range *credentials.Endpoints {
-					if endpointValue.Timeout != "" {
-						html5Destination.Properties["endpoints."+endpointKey+".timeout"] = endpointValue.Timeout
-						html5Destination.Properties["endpoints."+endpointKey+".url"] = endpointValue.URL
-					} else {
-						html5Destination.Properties["endpoints."+endpointKey] = endpointValue.URL
-					}
-				}
-			} else {
-				endpoints, err := json.Marshal(*credentials.Endpoints)
-				if err != nil {
-					return fmt.Errorf("Could not marshal business service endpoints")
-				}
-				html5Destination.Properties["endpoints"] = string(endpoints)
-			}
+	// Build destination configuration
+	html5Destination, err := buildHTML5Destination(credentials, options)
+	if err != nil {
+		return err
+	}
 
-		}
+	if existingDestination == nil {
+		log.Tracef("Creating new HTML5 destination\n")
 
 		// Create destination
 		if destinationInstance == "" {
 			err = clients.CreateSubaccountDestination(
 				*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
 				destinationContext.DestinationServiceInstanceKeyToken,
-				*html5Destination)
+				html5Destination)
 		} else {
 			err = clients.CreateServiceInstanceDestination(
 				*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
 				destinationContext.DestinationServiceInstanceKeyToken,
-				*html5Destination)
+				html5Destination)
 		}
 		if err != nil {
 			return fmt.Errorf("Could not create %s destination: %s", destinationLevel, err.Error())
 		}
 		log.Tracef("HTML5 destination created: %+v\n", html5Destination)
+	} else if err = checkDestinationRename(*existingDestination, options, destinationLevel); err != nil {
+		return err
+	} else if options.NoUpdate {
+		log.Tracef("HTML5 destination already exist and will not be updated: %+v\n", existingDestination)
 	} else {
-		log.Tracef("HTML5 destination already exist: %+v\n", html5Destination)
+		// Compare existing destination with desired one
+		changes := diffDestinations(*existingDestination, html5Destination)
+		if len(changes) == 0 {
+			log.Tracef("HTML5 destination is up to date: %+v\n", existingDestination)
+		} else {
+			ui.Say("Updating %s destination %s:", destinationLevel, terminal.EntityNameColor(existingDestination.Name))
+			printDestinationChanges(changes)
+			html5Destination = mergeDestinations(*existingDestination, html5Destination)
+
+			// Update destination
+			if destinationInstance == "" {
+				err = clients.UpdateSubaccountDestination(
+					*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
+					destinationContext.DestinationServiceInstanceKeyToken,
+					html5Destination)
+			} else {
+				err = clients.UpdateServiceInstanceDestination(
+					*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
+					destinationContext.DestinationServiceInstanceKeyToken,
+					html5Destination)
+			}
+			if err != nil {
+				return fmt.Errorf("Could not update %s destination: %s", destinationLevel, err.Error())
+			}
+			log.Tracef("HTML5 destination updated: %+v\n", log.Sensitive{Data: html5Destination})
+		}
 	}
 
 	// Clean-up destination context
@@ -930,7 +1515,7 @@ func (c *PushCommand) CreateHTML5Destination(context Context, credentials models
 	return nil
 }
 
-func findAppDirectories(cwd string, allowEmpty bool) ([]string, error) {
+func findAppDirectories(cwd string, allowEmpty bool, options PushOptions) ([]string, error) {
 	// Current working directory
 	log.Tracef("Checking if current working directory is an application directory\n")
 	if isAppDirectory(cwd) {
@@ -939,22 +1524,98 @@ func findAppDirectories(cwd string, allowEmpty bool) ([]string, error) {
 	}
 	// Folders in current working directory
 	var dirs = make([]string, 0)
-	files, err := ioutil.ReadDir(cwd)
-	if err != nil {
-		return dirs, errors.New("Could not read current working directory contents")
-	}
-	for _, file := range files {
-		if file.IsDir() && isAppDirectory(file.Name()) {
-			dirs = append(dirs, cwd+slash+file.Name())
+	if options.Recursive {
+		dirs = findAppDirectoriesRecursive(cwd, options.Depth)
+	} else {
+		files, err := ioutil.ReadDir(cwd)
+		if err != nil {
+			return dirs, errors.New("Could not read current working directory contents")
+		}
+		for _, file := range files {
+			if file.IsDir() && isAppDirectory(file.Name()) {
+				dirs = append(dirs, cwd+slash+file.Name())
+			}
 		}
 	}
 	if len(dirs) == 0 && !allowEmpty {
+		if options.Recursive {
+			return dirs, fmt.Errorf("Neither current working directory, nor one of it's subdirectories up to depth %d contains HTML5 application. Make sure manifest.json and xs-app.json exist", options.Depth)
+		}
 		return dirs, errors.New("Neither current working directory, nor one of it's subdirectories contains HTML5 application. Make sure manifest.json and xs-app.json exist")
 	}
 	log.Tracef("Pushing the following directories: %+v\n", dirs)
 	return dirs, nil
 }
 
+// findAppDirectoriesRecursive looks for application directories in subdirectories
+// of given directory up to given depth. Application directories are not searched
+// further, and well-known directories with dependencies and metadata are skipped
+func findAppDirectoriesRecursive(root string, depth int) []string {
+	var dirs = make([]string, 0)
+	if depth <= 0 {
+		return dirs
+	}
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		log.Tracef("Could not read contents of directory '%s': %+v\n", root, err)
+		return dirs
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if indexOfString(skippedDirectories, file.Name()) >= 0 {
+			log.Tracef("Skipping directory '%s'\n", filepath.Join(root, file.Name()))
+			continue
+		}
+		dir := filepath.Join(root, file.Name())
+		if isAppDirectory(dir) {
+			dirs = append(dirs, dir)
+			continue
+		}
+		dirs = append(dirs, findAppDirectoriesRecursive(dir, depth-1)...)
+	}
+	return dirs
+}
+
+// expandAppPaths expands glob patterns in application paths
+func expandAppPaths(appPaths []string) []string {
+	var paths = make([]string, 0)
+	for _, appPath := range appPaths {
+		if !strings.ContainsAny(appPath, "*?[") {
+			paths = append(paths, appPath)
+			continue
+		}
+		matches, err := filepath.Glob(appPath)
+		if err != nil || len(matches) == 0 {
+			log.Tracef("Glob pattern '%s' does not match any path: %+v\n", appPath, err)
+			paths = append(paths, appPath)
+			continue
+		}
+		log.Tracef("Glob pattern '%s' matches: %+v\n", appPath, matches)
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// appendUniquePath appends path to the list, if it's not there yet
+func appendUniquePath(paths []string, path string) []string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	for _, p := range paths {
+		absP, err := filepath.Abs(p)
+		if err != nil {
+			absP = p
+		}
+		if absP == absPath {
+			return paths
+		}
+	}
+	return append(paths, path)
+}
+
 func isAppDirectory(path string) bool {
 	var err error
 
@@ -978,10 +1639,115 @@ func isAppDirectory(path string) bool {
 	return true
 }
 
-func zipit(sources []string, target string) error {
+func isAppArchive(path string) bool {
+	if strings.ToLower(filepath.Ext(path)) != ".zip" {
+		return false
+	}
+
+	log.Tracef("Checking if '%s' is an application archive\n", path)
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		log.Tracef("Could not open archive '%s': %+v\n", path, err)
+		return false
+	}
+	defer archive.Close()
+	hasManifest := false
+	hasAppDescriptor := false
+	for _, file := range archive.File {
+		switch file.Name {
+		case "manifest.json":
+			hasManifest = true
+		case "xs-app.json":
+			hasAppDescriptor = true
+		}
+	}
+	log.Tracef("Archive '%s' contains manifest.json: %t, xs-app.json: %t\n", path, hasManifest, hasAppDescriptor)
+
+	return hasManifest && hasAppDescriptor
+}
+
+func findAppArchives(path string) []string {
+	var archives = make([]string, 0)
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return archives
+	}
+	log.Tracef("Looking for application archives in '%s' directory\n", path)
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return archives
+	}
+	for _, file := range files {
+		archivePath := filepath.Join(path, file.Name())
+		if !file.IsDir() && isAppArchive(archivePath) {
+			archives = append(archives, archivePath)
+		}
+	}
+	return archives
+}
+
+// readAppFile reads file from application directory or application archive
+func readAppFile(appPath string, fileName string) ([]byte, error) {
+	if !isAppArchive(appPath) {
+		return ioutil.ReadFile(appPath + slash + fileName)
+	}
+	archive, err := zip.OpenReader(appPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.Name == fileName {
+			reader, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Close()
+			return ioutil.ReadAll(reader)
+		}
+	}
+	return nil, fmt.Errorf("Archive '%s' does not contain %s", appPath, fileName)
+}
+
+// zipAppDirectory zips contents of application directory
+func zipAppDirectory(appPath string, zipPath string, ignore *ignoreMatcher, transform *appTransformer) (zipStats, error) {
+	log.Tracef("Zipping the directory: '%s'\n", appPath)
+
+	var appPathFiles = make([]string, 0)
+	files, err := ioutil.ReadDir(appPath)
+	if err != nil {
+		return zipStats{}, fmt.Errorf("Could not read contents of application directory '%s' : %+v", appPath, err)
+	}
+	for _, file := range files {
+		log.Tracef("Adding file to archive: '%s'\n", appPath+slash+file.Name())
+		appPathFiles = append(appPathFiles, appPath+slash+file.Name())
+	}
+
+	stats, err := zipit(appPathFiles, zipPath, ignore, transform)
+	if err != nil {
+		return stats, fmt.Errorf("Could not zip application directory '%s' : %+v", zipPath, err)
+	}
+
+	return stats, nil
+}
+
+// zipStats statistics of created archive
+type zipStats struct {
+	// Number of files excluded by ignore file
+	ExcludedFiles int
+	// Size of files excluded by ignore file
+	ExcludedBytes int64
+	// Number of folders excluded by ignore file. Content
+	// of excluded folders is not walked and not counted
+	ExcludedDirs int
+}
+
+func zipit(sources []string, target string, ignore *ignoreMatcher, transform *appTransformer) (zipStats, error) {
+	var stats zipStats
+
 	zipfile, err := os.Create(target)
 	if err != nil {
-		return err
+		return stats, err
 	}
 	defer zipfile.Close()
 
@@ -991,11 +1757,11 @@ func zipit(sources []string, target string) error {
 	for _, source := range sources {
 		source, err = filepath.Abs(source)
 		if err != nil {
-			return nil
+			return stats, err
 		}
 		info, err := os.Stat(source)
 		if err != nil {
-			return nil
+			return stats, err
 		}
 
 		var baseDir string
@@ -1003,7 +1769,7 @@ func zipit(sources []string, target string) error {
 			baseDir = filepath.Base(source)
 		}
 
-		filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
@@ -1017,6 +1783,19 @@ func zipit(sources []string, target string) error {
 				header.Name = strings.Replace(filepath.Join(baseDir, strings.TrimPrefix(path, source)), "\\", "/", -1)
 			}
 
+			// Skip ignored files and folders
+			if ignore.Ignored(header.Name, info.IsDir()) {
+				if info.IsDir() {
+					log.Tracef("Ignoring folder: '%s'\n", path)
+					stats.ExcludedDirs++
+					return filepath.SkipDir
+				}
+				log.Tracef("Ignoring file: '%s'\n", path)
+				stats.ExcludedFiles++
+				stats.ExcludedBytes += info.Size()
+				return nil
+			}
+
 			if info.IsDir() {
 				header.Name += "/"
 			} else {
@@ -1032,6 +1811,20 @@ func zipit(sources []string, target string) error {
 				return nil
 			}
 
+			// Write transformed content
+			if transform.Applies(header.Name) {
+				content, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				content, err = transform.Transform(header.Name, content)
+				if err != nil {
+					return fmt.Errorf("Could not transform file '%s' : %+v", path, err)
+				}
+				_, err = writer.Write(content)
+				return err
+			}
+
 			file, err := os.Open(path)
 			if err != nil {
 				return err
@@ -1040,7 +1833,10 @@ func zipit(sources []string, target string) error {
 			_, err = io.Copy(writer, file)
 			return err
 		})
+		if err != nil {
+			return stats, err
+		}
 	}
 
-	return err
+	return stats, err
 }
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"bytes"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name of html5-push project configuration file in current working directory
+const pushConfigFileName = "html5-push.json"
+
+// PushConfig project configuration of html5-push command
+type PushConfig struct {
+	// Name of app-host service instance created by html5-push
+	AppHostName string `json:"appHostName,omitempty"`
+	// Parameters of app-host service instance created by html5-push:
+	// JSON object or path to JSON file relative to configuration file
+	AppHostParams json.RawMessage `json:"appHostParams,omitempty"`
+}
+
+// loadPushConfig reads html5-push project configuration file from given
+// directory. Returns empty configuration if file does not exist
+func loadPushConfig(dir string) (PushConfig, error) {
+	var config PushConfig
+
+	fileName := filepath.Join(dir, pushConfigFileName)
+	fileContents, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		log.Tracef("Project configuration file '%s' does not exist\n", fileName)
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("Could not read project configuration file '%s': %s", fileName, err.Error())
+	}
+	log.Tracef("Reading project configuration file '%s': %s\n", fileName, string(fileContents))
+	decoder := json.NewDecoder(bytes.NewReader(fileContents))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&config)
+	if err != nil {
+		return config, fmt.Errorf("Could not parse project configuration file '%s': %s", fileName, err.Error())
+	}
+
+	// Resolve path to parameters file relative to configuration file
+	if len(config.AppHostParams) > 0 {
+		var paramsFile string
+		if json.Unmarshal(config.AppHostParams, &paramsFile) == nil && !filepath.IsAbs(paramsFile) {
+			paramsFileJSON, _ := json.Marshal(filepath.Join(dir, paramsFile))
+			config.AppHostParams = paramsFileJSON
+		}
+	}
+
+	return config, nil
+}
+
+// GetAppHostParams returns parameters of app-host service instance
+// defined in project configuration file
+func (config PushConfig) GetAppHostParams() (interface{}, error) {
+	if len(config.AppHostParams) == 0 {
+		return nil, nil
+	}
+	var paramsFile string
+	if json.Unmarshal(config.AppHostParams, &paramsFile) == nil {
+		return parseJSONParameter(paramsFile)
+	}
+	var params interface{}
+	err := json.Unmarshal(config.AppHostParams, &params)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// parseJSONParameter parses command line parameter, which is either
+// inline JSON object or path to the file with JSON object
+func parseJSONParameter(value string) (interface{}, error) {
+	var result interface{}
+	var err error
+
+	jsonValue := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		log.Tracef("Reading JSON from file '%s'\n", value)
+		jsonValue, err = ioutil.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read JSON file '%s': %s", value, err.Error())
+		}
+	}
+	err = json.Unmarshal(jsonValue, &result)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JSON '%s': %s", value, err.Error())
+	}
+	if _, ok := result.(map[string]interface{}); !ok {
+		return nil, errors.New("JSON object expected, but got: " + value)
+	}
+
+	return result, nil
+}
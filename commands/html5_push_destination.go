@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Properties of destination, which values are not printed
+var secretDestinationProperties = []string{"clientSecret"}
+
+// Properties of destination, which are defined by html5-push
+// and can not be set with --destination-property option
+var reservedDestinationProperties = []string{
+	"Name", "Description", "Type", "URL", "Authentication", "ProxyType",
+	"tokenServiceURL", "tokenServiceURLType", "clientId", "clientSecret",
+	"sap.cloud.service", "xsappname", "html5-apps-repo",
+}
+
+// DestinationChange change of destination property
+type DestinationChange struct {
+	Property string
+	Current  string
+	Desired  string
+}
+
+// parseDestinationProperties parses additional destination properties
+// passed as key=value
+func parseDestinationProperties(values []string) (map[string]string, error) {
+	properties := make(map[string]string)
+	for _, value := range values {
+		keyValue := strings.SplitN(value, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+		if len(keyValue) != 2 || key == "" {
+			return nil, fmt.Errorf("Destination property '%s' should be defined as key=value", value)
+		}
+		for _, reserved := range reservedDestinationProperties {
+			if strings.EqualFold(key, reserved) {
+				return nil, fmt.Errorf("Destination property '%s' is defined by html5-push and can not be changed", key)
+			}
+		}
+		if _, ok := properties[key]; ok {
+			return nil, fmt.Errorf("Destination property '%s' is defined more than once", key)
+		}
+		properties[key] = keyValue[1]
+	}
+	return properties, nil
+}
+
+// findHTML5Destination returns destination with given sap.cloud.service
+// property or nil, if there is no such destination
+func findHTML5Destination(destinations models.DestinationListDestinationsResponse, sapCloudService string) *models.DestinationConfiguration {
+	for idx := range destinations {
+		if destinations[idx].Properties["sap.cloud.service"] == sapCloudService {
+			return &destinations[idx]
+		}
+	}
+	return nil
+}
+
+// buildHTML5Destination returns destination configuration with service
+// credentials, "sap.cloud.service", "app-host-id" and custom properties
+func buildHTML5Destination(credentials models.CFCredentials, options PushOptions) (models.DestinationConfiguration, error) {
+	uri := ""
+	if credentials.URI != nil {
+		uri = *credentials.URI
+	}
+	appHostID := ""
+	if credentials.HTML5AppsRepo != nil {
+		appHostID = credentials.HTML5AppsRepo.AppHostID
+	}
+	tokenServiceURL := ""
+	if credentials.UAA.URL != "" {
+		tokenServiceURL = credentials.UAA.URL + "/oauth/token"
+	}
+
+	// Build destination configuration
+	html5Destination := models.DestinationConfiguration{
+		Name:                strings.Replace(*credentials.SapCloudService, ".", "", -1),
+		Description:         "Business Service Destination",
+		Type:                "HTTP",
+		URL:                 uri,
+		Authentication:      "OAuth2ClientCredentials",
+		ProxyType:           "Internet",
+		TokenServiceURL:     tokenServiceURL,
+		TokenServiceURLType: "Dedicated",
+		ClientID:            credentials.UAA.ClientID,
+		ClientSecret:        credentials.UAA.ClientSecret,
+		Properties: map[string]string{
+			"sap.cloud.service": *credentials.SapCloudService,
+			"xsappname":         credentials.UAA.XSAPPNAME,
+		},
+	}
+
+	// html5-apps-repo
+	if appHostID != "" {
+		if os.Getenv("HTML5_COMPATIBILITY") == "1.4.3" {
+			html5Destination.Properties["html5-apps-repo.app_host_id"] = appHostID
+		} else {
+			html5Destination.Properties["html5-apps-repo"] = "{\"app_host_id\":\"" + appHostID + "\"}"
+		}
+	}
+
+	// Endpoints
+	if credentials.Endpoints != nil {
+		log.Tracef("Destination endpoints: %+v\n", *credentials.Endpoints)
+		if os.Getenv("HTML5_COMPATIBILITY") == "1.4.3" {
+			for endpointKey, endpointValue := range *credentials.Endpoints {
+				if endpointValue.Timeout != "" {
+					html5Destination.Properties["endpoints."+endpointKey+".timeout"] = endpointValue.Timeout
+					html5Destination.Properties["endpoints."+endpointKey+".url"] = endpointValue.URL
+				} else {
+					html5Destination.Properties["endpoints."+endpointKey] = endpointValue.URL
+				}
+			}
+		} else {
+			endpoints, err := json.Marshal(*credentials.Endpoints)
+			if err != nil {
+				return html5Destination, fmt.Errorf("Could not marshal business service endpoints")
+			}
+			html5Destination.Properties["endpoints"] = string(endpoints)
+		}
+	}
+
+	// Custom name, description and properties
+	if options.DestinationName != "" {
+		html5Destination.Name = options.DestinationName
+	}
+	if options.DestinationDescription != "" {
+		html5Destination.Description = options.DestinationDescription
+	}
+	for key, value := range options.DestinationProperties {
+		html5Destination.Properties[key] = value
+	}
+
+	return html5Destination, nil
+}
+
+// checkDestinationRename returns error, if existing destination would have
+// to be renamed to destination name given in options
+func checkDestinationRename(existing models.DestinationConfiguration, options PushOptions, destinationLevel string) error {
+	if options.DestinationName != "" && options.DestinationName != existing.Name && !options.NoUpdate {
+		return fmt.Errorf("Could not rename %s destination '%s' with sap.cloud.service '%s' to '%s'. "+
+			"Delete existing destination or use its name", destinationLevel, existing.Name, existing.Properties["sap.cloud.service"], options.DestinationName)
+	}
+	return nil
+}
+
+// getDestinationProperties returns all properties of destination configuration
+// by their names in destination service
+func getDestinationProperties(destination models.DestinationConfiguration) map[string]string {
+	properties := map[string]string{
+		"Description":         destination.Description,
+		"Type":                destination.Type,
+		"URL":                 destination.URL,
+		"Authentication":      destination.Authentication,
+		"ProxyType":           destination.ProxyType,
+		"tokenServiceURL":     destination.TokenServiceURL,
+		"tokenServiceURLType": destination.TokenServiceURLType,
+		"clientId":            destination.ClientID,
+		"clientSecret":        destination.ClientSecret,
+	}
+	for key, value := range destination.Properties {
+		properties[key] = value
+	}
+	return properties
+}
+
+// diffDestinations compares properties of existing destination with desired
+// destination. Properties not defined in desired destination are ignored
+func diffDestinations(current models.DestinationConfiguration, desired models.DestinationConfiguration) []DestinationChange {
+	changes := make([]DestinationChange, 0)
+	currentProperties := getDestinationProperties(current)
+	desiredProperties := getDestinationProperties(desired)
+	for property, desiredValue := range desiredProperties {
+		if desiredValue == "" {
+			continue
+		}
+		if currentValue := currentProperties[property]; currentValue != desiredValue {
+			changes = append(changes, DestinationChange{Property: property, Current: currentValue, Desired: desiredValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Property < changes[j].Property
+	})
+	return changes
+}
+
+// mergeDestinations returns existing destination with properties of desired
+// destination. Name and properties not defined in desired destination are kept
+func mergeDestinations(current models.DestinationConfiguration, desired models.DestinationConfiguration) models.DestinationConfiguration {
+	merged := current
+	merged.Properties = make(map[string]string)
+	for key, value := range current.Properties {
+		merged.Properties[key] = value
+	}
+	if desired.Description != "" {
+		merged.Description = desired.Description
+	}
+	if desired.Type != "" {
+		merged.Type = desired.Type
+	}
+	if desired.URL != "" {
+		merged.URL = desired.URL
+	}
+	if desired.Authentication != "" {
+		merged.Authentication = desired.Authentication
+	}
+	if desired.ProxyType != "" {
+		merged.ProxyType = desired.ProxyType
+	}
+	if desired.TokenServiceURL != "" {
+		merged.TokenServiceURL = desired.TokenServiceURL
+	}
+	if desired.TokenServiceURLType != "" {
+		merged.TokenServiceURLType = desired.TokenServiceURLType
+	}
+	if desired.ClientID != "" {
+		merged.ClientID = desired.ClientID
+	}
+	if desired.ClientSecret != "" {
+		merged.ClientSecret = desired.ClientSecret
+	}
+	for key, value := range desired.Properties {
+		if value != "" {
+			merged.Properties[key] = value
+		}
+	}
+	return merged
+}
+
+// printDestinationChanges prints table of changed destination properties.
+// Values of secret properties are masked
+func printDestinationChanges(changes []DestinationChange) {
+	table := ui.Table([]string{"property", "current value", "new value"})
+	for _, change := range changes {
+		current, desired := change.Current, change.Desired
+		if indexOfString(secretDestinationProperties, change.Property) >= 0 {
+			current, desired = maskSecret(current), maskSecret(desired)
+		}
+		table.Add(change.Property, current, desired)
+	}
+	table.Print()
+	ui.Say("")
+}
+
+// maskSecret replaces non-empty secret value with asterisks
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
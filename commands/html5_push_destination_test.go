@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"reflect"
+	"testing"
+)
+
+func TestDiffDestinations(t *testing.T) {
+	current := models.DestinationConfiguration{
+		Name:            "my-service",
+		Type:            "HTTP",
+		URL:             "https://old.example.com",
+		Authentication:  "OAuth2ClientCredentials",
+		ProxyType:       "Internet",
+		TokenServiceURL: "https://old.example.com/oauth/token",
+		ClientID:        "client",
+		ClientSecret:    "secret",
+		Properties: map[string]string{
+			"sap.cloud.service": "my.service",
+			"custom":            "value",
+		},
+	}
+	tests := []struct {
+		name     string
+		desired  models.DestinationConfiguration
+		expected []DestinationChange
+	}{
+		{
+			name:     "same",
+			desired:  current,
+			expected: []DestinationChange{},
+		},
+		{
+			name:     "empty",
+			desired:  models.DestinationConfiguration{Name: "other"},
+			expected: []DestinationChange{},
+		},
+		{
+			name: "changed",
+			desired: models.DestinationConfiguration{
+				Name:            "my-service",
+				Type:            "HTTP",
+				URL:             "https://new.example.com",
+				TokenServiceURL: "https://new.example.com/oauth/token",
+				ClientSecret:    "new-secret",
+				Properties: map[string]string{
+					"sap.cloud.service": "my.service",
+					"custom":            "",
+					"html5-apps-repo":   "{\"app_host_id\":\"1\"}",
+				},
+			},
+			expected: []DestinationChange{
+				{Property: "URL", Current: "https://old.example.com", Desired: "https://new.example.com"},
+				{Property: "clientSecret", Current: "secret", Desired: "new-secret"},
+				{Property: "html5-apps-repo", Current: "", Desired: "{\"app_host_id\":\"1\"}"},
+				{Property: "tokenServiceURL", Current: "https://old.example.com/oauth/token", Desired: "https://new.example.com/oauth/token"},
+			},
+		},
+	}
+	for _, test := range tests {
+		changes := diffDestinations(current, test.desired)
+		if !reflect.DeepEqual(changes, test.expected) {
+			t.Errorf("%s: diffDestinations returned %+v, expected %+v", test.name, changes, test.expected)
+		}
+	}
+}
+
+func TestMergeDestinations(t *testing.T) {
+	current := models.DestinationConfiguration{
+		Name:         "my-service",
+		Description:  "Old",
+		Type:         "HTTP",
+		URL:          "https://old.example.com",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Properties:   map[string]string{"custom": "value", "other": "kept"},
+	}
+	desired := models.DestinationConfiguration{
+		Name:         "ignored",
+		URL:          "https://new.example.com",
+		ClientSecret: "new-secret",
+		Properties:   map[string]string{"custom": "new", "other": "", "added": "yes"},
+	}
+	expected := models.DestinationConfiguration{
+		Name:         "my-service",
+		Description:  "Old",
+		Type:         "HTTP",
+		URL:          "https://new.example.com",
+		ClientID:     "client",
+		ClientSecret: "new-secret",
+		Properties:   map[string]string{"custom": "new", "other": "kept", "added": "yes"},
+	}
+
+	merged := mergeDestinations(current, desired)
+
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("mergeDestinations returned %+v, expected %+v", merged, expected)
+	}
+	if current.Properties["custom"] != "value" {
+		t.Errorf("mergeDestinations changed properties of current destination: %+v", current.Properties)
+	}
+	if changes := diffDestinations(merged, desired); len(changes) != 0 {
+		t.Errorf("Merged destination differs from desired: %+v", changes)
+	}
+}
+
+func TestBuildHTML5Destination(t *testing.T) {
+	sapCloudService := "my.service"
+	uri := "https://runtime.example.com"
+	credentials := models.CFCredentials{
+		URI:             &uri,
+		UAA:             &models.CFUAA{URL: "https://uaa.example.com", ClientID: "client", ClientSecret: "secret", XSAPPNAME: "app"},
+		SapCloudService: &sapCloudService,
+		HTML5AppsRepo:   &models.HTML5AppsRepo{AppHostID: "app-host-1"},
+	}
+	options := PushOptions{DestinationName: "custom", DestinationProperties: map[string]string{"custom": "value"}}
+
+	destination, err := buildHTML5Destination(credentials, options)
+	if err != nil {
+		t.Fatalf("buildHTML5Destination returned error: %s", err.Error())
+	}
+	expected := models.DestinationConfiguration{
+		Name:                "custom",
+		Description:         "Business Service Destination",
+		Type:                "HTTP",
+		URL:                 uri,
+		Authentication:      "OAuth2ClientCredentials",
+		ProxyType:           "Internet",
+		TokenServiceURL:     "https://uaa.example.com/oauth/token",
+		TokenServiceURLType: "Dedicated",
+		ClientID:            "client",
+		ClientSecret:        "secret",
+		Properties: map[string]string{
+			"sap.cloud.service": "my.service",
+			"xsappname":         "app",
+			"html5-apps-repo":   `{"app_host_id":"app-host-1"}`,
+			"custom":            "value",
+		},
+	}
+	if !reflect.DeepEqual(destination, expected) {
+		t.Errorf("buildHTML5Destination returned %+v, expected %+v", destination, expected)
+	}
+
+	// Credentials of service key, which is not created yet
+	destination, err = buildHTML5Destination(models.CFCredentials{UAA: &models.CFUAA{}, SapCloudService: &sapCloudService}, PushOptions{})
+	if err != nil {
+		t.Fatalf("buildHTML5Destination returned error: %s", err.Error())
+	}
+	if destination.Name != "myservice" || destination.URL != "" || destination.TokenServiceURL != "" || destination.ClientID != "" {
+		t.Errorf("buildHTML5Destination without credentials returned %+v", destination)
+	}
+}
+
+func TestCheckDestinationRename(t *testing.T) {
+	existing := models.DestinationConfiguration{Name: "myservice", Properties: map[string]string{"sap.cloud.service": "my.service"}}
+	tests := []struct {
+		options PushOptions
+		fails   bool
+	}{
+		{options: PushOptions{}},
+		{options: PushOptions{DestinationName: "myservice"}},
+		{options: PushOptions{DestinationName: "other"}, fails: true},
+		{options: PushOptions{DestinationName: "other", NoUpdate: true}},
+	}
+	for _, test := range tests {
+		if err := checkDestinationRename(existing, test.options, "subaccount"); (err != nil) != test.fails {
+			t.Errorf("checkDestinationRename(%+v) returned error %v, expected failure = %v", test.options, err, test.fails)
+		}
+	}
+}
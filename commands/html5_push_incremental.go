@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"archive/zip"
+	"cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ETag values, which look like hex-encoded MD5, SHA-1 or SHA-256 hashes
+var hashETagRegexp = regexp.MustCompile(`^([0-9a-f]{32}|[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// archiveFile size and hashes of file in application archive
+type archiveFile struct {
+	Size   int
+	MD5    string
+	SHA1   string
+	SHA256 string
+}
+
+// getArchiveFiles returns size and hashes of each file in archive,
+// by path relative to application root
+func getArchiveFiles(zipFile string) (map[string]archiveFile, error) {
+	files := make(map[string]archiveFile)
+
+	archive, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return files, err
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return files, err
+		}
+		md5Hash := md5.New()
+		sha1Hash := sha1.New()
+		sha256Hash := sha256.New()
+		size, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), reader)
+		reader.Close()
+		if err != nil {
+			return files, err
+		}
+		files[file.Name] = archiveFile{
+			Size:   int(size),
+			MD5:    hex.EncodeToString(md5Hash.Sum(nil)),
+			SHA1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+			SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+		}
+	}
+
+	return files, nil
+}
+
+// isAppUnchanged checks if content of application archive is identical
+// to the content of the same application version deployed to app-host
+func isAppUnchanged(html5Context HTML5Context, appHostGUID string, appKey string, zipFile string) (bool, error) {
+	serviceURL := *html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI
+	accessToken := html5Context.HTML5AppRuntimeServiceInstanceKeyToken
+
+	// Local files
+	localFiles, err := getArchiveFiles(zipFile)
+	if err != nil {
+		return false, fmt.Errorf("Could not read archive '%s': %+v", zipFile, err)
+	}
+
+	// Deployed files
+	deployedFiles, err := getDeployedAppFiles(html5Context, appHostGUID, appKey)
+	if err != nil {
+		return false, err
+	}
+
+	// Compare list of files
+	if len(localFiles) != len(deployedFiles) {
+		log.Tracef("Application %s has %d files, deployed version has %d files\n", appKey, len(localFiles), len(deployedFiles))
+		return false, nil
+	}
+	for _, deployedFile := range deployedFiles {
+		path := strings.TrimPrefix(deployedFile.FilePath, "/")
+		path = strings.TrimPrefix(path, appKey+"/")
+		localFile, ok := localFiles[path]
+		if !ok {
+			log.Tracef("File %s of deployed application %s does not exist locally\n", path, appKey)
+			return false, nil
+		}
+		if localFile.Size != deployedFile.FileMetadata.FileSize {
+			log.Tracef("File %s of application %s has size %d, deployed file has size %d\n", path, appKey, localFile.Size, deployedFile.FileMetadata.FileSize)
+			return false, nil
+		}
+
+		// Compare ETag with hashes of local file
+		etag := strings.ToLower(strings.Trim(strings.TrimPrefix(deployedFile.FileMetadata.ETag, "W/"), "\""))
+		if hashETagRegexp.MatchString(etag) {
+			if etag != localFile.MD5 && etag != localFile.SHA1 && etag != localFile.SHA256 {
+				log.Tracef("File %s of application %s has different content (ETag: %s)\n", path, appKey, etag)
+				return false, nil
+			}
+			continue
+		}
+
+		// Compare content, if ETag is not a hash
+		log.Tracef("ETag '%s' of file %s is not a content hash, comparing content\n", etag, path)
+		content, _, err := clients.GetFileContentReader(serviceURL, deployedFile.FilePath, accessToken, appHostGUID)
+		if err != nil {
+			return false, fmt.Errorf("Could not get content of file %s: %+v", deployedFile.FilePath, err)
+		}
+		contentHash := sha256.New()
+		_, err = io.Copy(contentHash, content)
+		content.Close()
+		if err != nil {
+			return false, fmt.Errorf("Could not get content of file %s: %+v", deployedFile.FilePath, err)
+		}
+		if hex.EncodeToString(contentHash.Sum(nil)) != localFile.SHA256 {
+			log.Tracef("File %s of application %s has different content\n", path, appKey)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"encoding/json"
+	"sort"
+
+	"github.com/cloudfoundry/cli/cf/terminal"
+)
+
+// PushPlan deployment plan of html5-push command
+type PushPlan struct {
+	// Applications to be pushed
+	Apps []PushPlanApp
+	// GUID of target app-host service instance (empty, if new one is created)
+	AppHostGUID string
+	// Name of target app-host service instance
+	AppHostName string
+	// New app-host service instance should be created
+	CreateAppHost bool
+	// Parameters of app-host service instance to be created
+	AppHostParams interface{}
+	// Content of existing app-host should be saved to local snapshot store
+	Snapshot bool
+	// Name of xsuaa service instance to be created or updated (empty, if not needed)
+	XSUAAServiceInstanceName string
+	// Existing xsuaa service instance should be updated
+	UpdateXSUAAServiceInstance bool
+	// Security descriptor of xsuaa service instance to be created
+	SecurityDescriptor map[string]interface{}
+	// Destinations to be created or updated
+	Destinations []PushPlanDestination
+	// Service keys to be created
+	ServiceKeys []PushPlanServiceKey
+}
+
+// PushPlanApp application to be pushed
+type PushPlanApp struct {
+	Name    string
+	Version string
+	Service string
+	Path    string
+}
+
+// PushPlanDestination destination to be created or updated
+type PushPlanDestination struct {
+	// Value of sap.cloud.service destination property
+	SapCloudService string
+	// Name of destination service instance (empty for subaccount level destination)
+	DestinationInstance string
+	// Name of service instance, which credentials are used by destination
+	CredentialsSource string
+	// Action on destination: create, update or no-op
+	Action string
+	// Name of existing or created destination
+	Name string
+	// Changes of existing destination to be updated
+	Changes []DestinationChange
+}
+
+// PushPlanServiceKey service key to be created
+type PushPlanServiceKey struct {
+	// Name of service instance, for which service key is created
+	ServiceInstance string
+	// Service key is deleted after push
+	Temporary bool
+}
+
+// PrintPushPlan prints deployment plan of html5-push command
+func (c *PushCommand) PrintPushPlan(plan PushPlan) ExecutionStatus {
+	log.Tracef("Printing deployment plan: %+v\n", plan)
+
+	ui.Ok()
+	ui.Say("")
+
+	// Target app-host
+	appHost := plan.AppHostName
+	if plan.AppHostGUID != "" {
+		if appHost == "" {
+			appHost = plan.AppHostGUID
+		} else {
+			appHost = appHost + " (" + plan.AppHostGUID + ")"
+		}
+	}
+
+	// Applications
+	if len(plan.Apps) > 0 {
+		ui.Say("Applications:")
+		table := ui.Table([]string{"name", "version", "service name", "path", "app-host"})
+		for _, app := range plan.Apps {
+			table.Add(app.Name, app.Version, app.Service, app.Path, appHost)
+		}
+		table.Print()
+		ui.Say("")
+	}
+
+	// Service instances and destinations
+	ui.Say("Changes:")
+	table := ui.Table([]string{"action", "resource", "name", "details"})
+	if len(plan.Apps) > 0 {
+		if plan.CreateAppHost {
+			details := ""
+			if plan.AppHostParams != nil {
+				appHostParamsJSON, err := json.Marshal(plan.AppHostParams)
+				if err != nil {
+					ui.Failed("Could not marshal app-host parameters: %+v", err)
+					return Failure
+				}
+				details = string(appHostParamsJSON)
+			}
+			table.Add(terminal.AdvisoryColor("create"), "app-host service instance", plan.AppHostName, details)
+		}
+		if plan.Snapshot {
+			table.Add(terminal.AdvisoryColor("snapshot"), "applications", appHost, "saved to "+getSnapshotsDir(plan.AppHostGUID))
+		}
+		table.Add(terminal.AdvisoryColor("upload"), "applications", appHost, "")
+	}
+	if plan.XSUAAServiceInstanceName != "" {
+		details := ""
+		if plan.SecurityDescriptor != nil {
+			securityDescriptorJSON, err := json.Marshal(plan.SecurityDescriptor)
+			if err != nil {
+				ui.Failed("Could not marshal security descriptor: %+v", err)
+				return Failure
+			}
+			details = string(securityDescriptorJSON)
+		}
+		action := "create"
+		if plan.UpdateXSUAAServiceInstance {
+			action = "update"
+		}
+		table.Add(terminal.AdvisoryColor(action), "xsuaa service instance", plan.XSUAAServiceInstanceName, details)
+	}
+	for _, serviceKey := range plan.ServiceKeys {
+		details := ""
+		if serviceKey.Temporary {
+			details = "deleted after upload"
+		}
+		table.Add(terminal.AdvisoryColor("create"), "service key", "for "+serviceKey.ServiceInstance, details)
+	}
+	for _, destination := range plan.Destinations {
+		level := "subaccount destination"
+		if destination.DestinationInstance != "" {
+			level = "service instance destination"
+		}
+		details := "sap.cloud.service=" + destination.SapCloudService + ", credentials of " + destination.CredentialsSource
+		if destination.DestinationInstance != "" {
+			details = details + ", destination service instance " + destination.DestinationInstance
+		}
+		table.Add(terminal.AdvisoryColor(destination.Action), level, destination.Name, details)
+	}
+	table.Print()
+	ui.Say("")
+
+	// Changes of updated destinations
+	for _, destination := range plan.Destinations {
+		if len(destination.Changes) > 0 {
+			ui.Say("Changes of destination %s:", terminal.EntityNameColor(destination.Name))
+			printDestinationChanges(destination.Changes)
+		}
+	}
+
+	return Success
+}
+
+// PlanHTML5Destination looks up destination with sap.cloud.service of given
+// credentials and decides, whether it would be created, updated or kept as is.
+// Credentials of service key, which is not created yet, are not known and
+// existing destination would be updated with credentials of new service key
+func (c *PushCommand) PlanHTML5Destination(context Context, credentials models.CFCredentials, credentialsSource string, newServiceKey bool, options PushOptions) (PushPlanDestination, error) {
+	destinationLevel := "subaccount"
+	if options.DestinationInstance != "" {
+		destinationLevel = "service instance"
+	}
+	plan := PushPlanDestination{
+		SapCloudService:     *credentials.SapCloudService,
+		DestinationInstance: options.DestinationInstance,
+		CredentialsSource:   credentialsSource,
+	}
+	if credentials.UAA == nil {
+		credentials.UAA = &models.CFUAA{}
+	}
+
+	// Desired destination
+	desired, err := buildHTML5Destination(credentials, options)
+	if err != nil {
+		return plan, err
+	}
+
+	// Existing destination
+	log.Tracef("Looking for %s destination with sap.cloud.service '%s'\n", destinationLevel, plan.SapCloudService)
+	destinations, destinationContext, err := c.getDestinations(context, options.DestinationInstance)
+	if err != nil {
+		return plan, err
+	}
+	err = c.CleanDestinationContext(destinationContext)
+	if err != nil {
+		return plan, err
+	}
+	existing := findHTML5Destination(destinations, plan.SapCloudService)
+
+	if existing == nil {
+		plan.Action, plan.Name = "create", desired.Name
+		return plan, nil
+	}
+	plan.Name = existing.Name
+	if err = checkDestinationRename(*existing, options, destinationLevel); err != nil {
+		return plan, err
+	}
+	if !options.NoUpdate {
+		plan.Changes = diffDestinations(*existing, desired)
+		if newServiceKey {
+			plan.Changes = append(plan.Changes,
+				DestinationChange{Property: "clientId", Current: existing.ClientID, Desired: "<new service key>"},
+				DestinationChange{Property: "clientSecret", Current: existing.ClientSecret, Desired: "<new service key>"})
+			sort.Slice(plan.Changes, func(i, j int) bool {
+				return plan.Changes[i].Property < plan.Changes[j].Property
+			})
+		}
+	}
+	plan.Action = "no-op"
+	if len(plan.Changes) > 0 {
+		plan.Action = "update"
+	}
+
+	return plan, nil
+}
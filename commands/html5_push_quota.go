@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"archive/zip"
+	"cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Number of biggest files shown when size limit of app-host is exceeded
+const biggestFilesCount = 10
+
+// appSize uncompressed size of application archive
+type appSize struct {
+	Name    string
+	Version string
+	Size    int
+	Files   []appFileSize
+	// Size of application with the same name and version,
+	// which is replaced in app-host
+	ReplacedSize int
+}
+
+// appFileSize uncompressed size of application file
+type appFileSize struct {
+	App  string
+	Path string
+	Size int
+}
+
+// CheckAppHostQuota checks that uncompressed size of applications does not
+// exceed remaining size limit of app-host, taking into account applications
+// with the same name and version, which are replaced during upload
+func (c *PushCommand) CheckAppHostQuota(context Context, html5Context *HTML5Context, appHostGUID string,
+	serviceURL string, accessToken string, zipFiles []string, appNames []string, appVersions []string) error {
+	var err error
+
+	// Get app-host size and size limit
+	meta, err := getAppHostMeta(serviceURL, accessToken, appHostGUID)
+	if err != nil {
+		return err
+	}
+	if meta.SizeLimit <= 0 {
+		log.Tracef("Size limit of app-host-id '%s' is unknown, skipping quota check\n", appHostGUID)
+		return nil
+	}
+
+	// Calculate uncompressed size of applications
+	sizes := make([]appSize, 0)
+	totalSize := 0
+	for idx, zipFile := range zipFiles {
+		size, err := getArchiveSize(zipFile)
+		if err != nil {
+			return fmt.Errorf("Could not read archive '%s': %+v", zipFile, err)
+		}
+		size.Name = appNames[idx]
+		size.Version = appVersions[idx]
+		for fileIdx := range size.Files {
+			size.Files[fileIdx].App = appNames[idx]
+		}
+		sizes = append(sizes, size)
+		totalSize += size.Size
+	}
+
+	// Size of replaced applications matters only if applications do not fit
+	// into remaining size limit, but fit into size limit of app-host
+	if totalSize <= meta.SizeLimit-meta.Size {
+		log.Tracef("Size of applications: %d, size of app-host: %d, size limit: %d\n", totalSize, meta.Size, meta.SizeLimit)
+		return nil
+	}
+	replacedSizeKnown := totalSize <= meta.SizeLimit
+	replacedSize := 0
+	if replacedSizeKnown {
+		if html5Context.ServiceName == "" {
+			*html5Context, err = c.GetHTML5Context(context)
+			if err != nil {
+				return err
+			}
+		}
+		replacedSize, err = getReplacedAppSizes(*html5Context, appHostGUID, sizes)
+		if err != nil {
+			return err
+		}
+	}
+
+	remaining := meta.SizeLimit - meta.Size + replacedSize
+	log.Tracef("Size of applications: %d, size of app-host: %d, size limit: %d, size of replaced applications: %d\n",
+		totalSize, meta.Size, meta.SizeLimit, replacedSize)
+	if totalSize <= remaining {
+		return nil
+	}
+
+	// Print per-application breakdown
+	ui.Say("")
+	table := ui.Table([]string{"name", "version", "size", "replaced size", "biggest files"})
+	allFiles := make([]appFileSize, 0)
+	for _, size := range sizes {
+		biggest := make([]string, 0)
+		for i := 0; i < len(size.Files) && i < 3; i++ {
+			biggest = append(biggest, size.Files[i].Path+" ("+getReadableSize(size.Files[i].Size)+")")
+		}
+		replaced := "-"
+		if replacedSizeKnown {
+			replaced = getReadableSize(size.ReplacedSize)
+		}
+		table.Add(size.Name, size.Version, getReadableSize(size.Size), replaced, strings.Join(biggest, ", "))
+		allFiles = append(allFiles, size.Files...)
+	}
+	table.Print()
+	ui.Say("")
+
+	// Print biggest files of all applications
+	sort.SliceStable(allFiles, func(i, j int) bool { return allFiles[i].Size > allFiles[j].Size })
+	if len(allFiles) > biggestFilesCount {
+		allFiles = allFiles[:biggestFilesCount]
+	}
+	ui.Say("Biggest files:")
+	table = ui.Table([]string{"name", "path", "size"})
+	for _, file := range allFiles {
+		table.Add(file.App, file.Path, getReadableSize(file.Size))
+	}
+	table.Print()
+	ui.Say("")
+
+	if !replacedSizeKnown {
+		return fmt.Errorf("Size of applications %s exceeds size limit %s of app-host-id '%s'. "+
+			"Consider excluding the biggest files with .html5ignore file",
+			getReadableSize(totalSize), getReadableSize(meta.SizeLimit), appHostGUID)
+	}
+	return fmt.Errorf("Size of applications %s exceeds remaining size limit %s of app-host-id '%s' "+
+		"(size limit: %s, used: %s, replaced by upload: %s). "+
+		"Consider excluding the biggest files with .html5ignore file",
+		getReadableSize(totalSize), getReadableSize(remaining), appHostGUID,
+		getReadableSize(meta.SizeLimit), getReadableSize(meta.Size), getReadableSize(replacedSize))
+}
+
+// getReplacedAppSizes sets size of applications with the same name and
+// version deployed to app-host, and returns total size of replaced applications
+func getReplacedAppSizes(html5Context HTML5Context, appHostGUID string, sizes []appSize) (int, error) {
+	serviceURL := *html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI
+	accessToken := html5Context.HTML5AppRuntimeServiceInstanceKeyToken
+
+	log.Tracef("Getting list of applications for app-host-id '%s'\n", appHostGUID)
+	applications, err := clients.ListApplicationsForAppHost(serviceURL, accessToken, appHostGUID)
+	if err != nil {
+		return 0, fmt.Errorf("Could not get list of applications for app-host-id '%s': %+v", appHostGUID, err)
+	}
+
+	// Get list of files of replaced applications
+	files := make(models.HTML5ListApplicationFilesResponse, 0)
+	fileApps := make([]int, 0)
+	for idx := range sizes {
+		for _, application := range applications {
+			if application.ApplicationName != sizes[idx].Name || application.ApplicationVersion != sizes[idx].Version {
+				continue
+			}
+			appKey := sizes[idx].Name + "-" + sizes[idx].Version
+			appFiles, err := clients.ListFilesOfApp(serviceURL, appKey, accessToken, appHostGUID)
+			if err != nil {
+				return 0, fmt.Errorf("Could not get list of files for app %s: %+v", appKey, err)
+			}
+			for range appFiles {
+				fileApps = append(fileApps, idx)
+			}
+			files = append(files, appFiles...)
+		}
+	}
+
+	// Get size of files of all replaced applications at once
+	err = getFilesMetadata(serviceURL, accessToken, appHostGUID, files)
+	if err != nil {
+		return 0, err
+	}
+	replacedSize := 0
+	for idx, file := range files {
+		sizes[fileApps[idx]].ReplacedSize += file.FileMetadata.FileSize
+		replacedSize += file.FileMetadata.FileSize
+	}
+
+	return replacedSize, nil
+}
+
+// getDeployedAppFiles returns list of files of application with given
+// key (name-version) deployed to app-host, together with files metadata
+func getDeployedAppFiles(html5Context HTML5Context, appHostGUID string, appKey string) (models.HTML5ListApplicationFilesResponse, error) {
+	serviceURL := *html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI
+	accessToken := html5Context.HTML5AppRuntimeServiceInstanceKeyToken
+
+	// Get list of files
+	files, err := clients.ListFilesOfApp(serviceURL, appKey, accessToken, appHostGUID)
+	if err != nil {
+		return files, fmt.Errorf("Could not get list of files for app %s: %+v", appKey, err)
+	}
+
+	return files, getFilesMetadata(serviceURL, accessToken, appHostGUID, files)
+}
+
+// getFilesMetadata gets size and ETag of files deployed to app-host
+// and sets them as files metadata
+func getFilesMetadata(serviceURL string, accessToken string, appHostGUID string, files models.HTML5ListApplicationFilesResponse) error {
+	rateLimiter := make(chan int, maxConcurrentConnections)
+
+	// Get files size and etag
+	metas := make([]chan models.HTML5ApplicationFileMetadata, len(files))
+	for idx := range files {
+		metas[idx] = make(chan models.HTML5ApplicationFileMetadata)
+		go func(filePath string, idx int, metaChannel chan models.HTML5ApplicationFileMetadata) {
+			rateLimiter <- idx
+			clients.GetFileMeta(serviceURL, filePath, accessToken, appHostGUID, metaChannel)
+		}(files[idx].FilePath, idx, metas[idx])
+	}
+	// All results are received, so no request is left blocked on its channel
+	var err error
+	for i := 0; i < len(files); i++ {
+		var idx int = <-rateLimiter
+		files[idx].FileMetadata = <-metas[idx]
+		if files[idx].FileMetadata.Error != nil && err == nil {
+			err = fmt.Errorf("Could not get file metadata for file %s: %+v", files[idx].FilePath, files[idx].FileMetadata.Error)
+		}
+	}
+
+	return err
+}
+
+// getArchiveSize returns uncompressed size of archive files,
+// sorted from biggest to smallest
+func getArchiveSize(zipFile string) (appSize, error) {
+	var size appSize
+
+	archive, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return size, err
+	}
+	defer archive.Close()
+	size.Files = make([]appFileSize, 0)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		size.Files = append(size.Files, appFileSize{Path: file.Name, Size: int(file.UncompressedSize64)})
+		size.Size += int(file.UncompressedSize64)
+	}
+	sort.SliceStable(size.Files, func(i, j int) bool { return size.Files[i].Size > size.Files[j].Size })
+
+	return size, nil
+}
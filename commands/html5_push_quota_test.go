@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"archive/zip"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// writeTestArchive creates application archive with files of given sizes
+func writeTestArchive(t *testing.T, dir string, name string, sizes map[string]int) string {
+	zipPath := filepath.Join(dir, name+".zip")
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipFile.Close()
+	archive := zip.NewWriter(zipFile)
+	for fileName, size := range sizes {
+		writer, err := archive.Create(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write([]byte(strings.Repeat("x", size))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestCheckAppHostQuota(t *testing.T) {
+	// Deployed files of app-1.0.0 (60 bytes) and app-0.9.0 (500 bytes)
+	deployedFiles := map[string]string{
+		"/app-1.0.0/index.html": strings.Repeat("x", 40),
+		"/app-1.0.0/app.js":     strings.Repeat("x", 20),
+		"/app-0.9.0/index.html": strings.Repeat("x", 500),
+	}
+	deployedApps := models.HTML5ListApplicationsResponse{
+		{ApplicationName: "app", ApplicationVersion: "1.0.0"},
+		{ApplicationName: "app", ApplicationVersion: "0.9.0"},
+	}
+	tests := []struct {
+		name         string
+		meta         models.HTML5ServiceMeta
+		version      string
+		fails        bool
+		headRequests int32
+	}{
+		{
+			name:    "unknown size limit",
+			meta:    models.HTML5ServiceMeta{Size: 1000},
+			version: "1.0.0",
+		},
+		{
+			name:    "fits into remaining size limit",
+			meta:    models.HTML5ServiceMeta{Size: 900, SizeLimit: 1000},
+			version: "1.0.0",
+		},
+		{
+			name:         "fits with replaced application",
+			meta:         models.HTML5ServiceMeta{Size: 950, SizeLimit: 1000},
+			version:      "1.0.0",
+			headRequests: 2,
+		},
+		{
+			name:         "exceeds with replaced application",
+			meta:         models.HTML5ServiceMeta{Size: 990, SizeLimit: 1000},
+			version:      "1.0.0",
+			fails:        true,
+			headRequests: 2,
+		},
+		{
+			name:    "exceeds without replaced application",
+			meta:    models.HTML5ServiceMeta{Size: 950, SizeLimit: 1000},
+			version: "2.0.0",
+			fails:   true,
+		},
+		{
+			name:    "exceeds size limit",
+			meta:    models.HTML5ServiceMeta{Size: 500, SizeLimit: 90},
+			version: "1.0.0",
+			fails:   true,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "html5-quota-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Application of 100 bytes
+	zipPath := writeTestArchive(t, dir, "app", map[string]int{"index.html": 70, "app.js": 30})
+
+	for _, test := range tests {
+		appHost := &testAppHost{Files: deployedFiles, Meta: test.meta, Apps: deployedApps}
+		server := httptest.NewServer(appHost)
+		html5Context := newTestHTML5Context(server.URL)
+		command := &PushCommand{}
+
+		err = command.CheckAppHostQuota(Context{}, &html5Context, "app-host-1", server.URL, "token",
+			[]string{zipPath}, []string{"app"}, []string{test.version})
+
+		server.Close()
+		if (err != nil) != test.fails {
+			t.Errorf("%s: CheckAppHostQuota returned error %v, expected failure = %v", test.name, err, test.fails)
+		}
+		if requests := atomic.LoadInt32(&appHost.HeadRequests); requests != test.headRequests {
+			t.Errorf("%s: %d file metadata requests, expected %d", test.name, requests, test.headRequests)
+		}
+	}
+}
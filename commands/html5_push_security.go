@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"bytes"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// Name of security descriptor file in application folder
+const securityDescriptorFileName = "xs-security.json"
+
+// loadSecurityDescriptor reads XSUAA security descriptor from file
+func loadSecurityDescriptor(fileName string) (map[string]interface{}, error) {
+	log.Tracef("Reading security descriptor '%s'\n", fileName)
+	fileContents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read security descriptor '%s': %s", fileName, err.Error())
+	}
+	return parseSecurityDescriptor(fileName, fileContents)
+}
+
+// parseSecurityDescriptor parses XSUAA security descriptor. Descriptor is kept
+// as generic JSON object, so properties unknown to plugin are passed to XSUAA
+// unchanged. Numbers are kept as json.Number
+func parseSecurityDescriptor(fileName string, fileContents []byte) (map[string]interface{}, error) {
+	var securityDescriptor map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(fileContents))
+	decoder.UseNumber()
+	err := decoder.Decode(&securityDescriptor)
+	if err == nil && securityDescriptor == nil {
+		err = fmt.Errorf("Security descriptor should be an object")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse security descriptor '%s': %s", fileName, err.Error())
+	}
+	return securityDescriptor, nil
+}
+
+// findSecurityDescriptor looks for xs-security.json in application folders
+// and archives. Fails, if applications contain different security descriptors
+func findSecurityDescriptor(dirs []string) (map[string]interface{}, string, error) {
+	var result map[string]interface{}
+	var resultFileName string
+	for _, dir := range dirs {
+		fileName := dir + slash + securityDescriptorFileName
+		if !appFileExists(dir, securityDescriptorFileName) {
+			continue
+		}
+		fileContents, err := readAppFile(dir, securityDescriptorFileName)
+		if err != nil {
+			return nil, "", err
+		}
+		securityDescriptor, err := parseSecurityDescriptor(fileName, fileContents)
+		if err != nil {
+			return nil, "", err
+		}
+		if result != nil && !reflect.DeepEqual(result, securityDescriptor) {
+			return nil, "", fmt.Errorf("Security descriptors '%s' and '%s' are different. "+
+				"Use --security-descriptor option to choose security descriptor", resultFileName, fileName)
+		}
+		result = securityDescriptor
+		resultFileName = fileName
+	}
+	if result != nil {
+		log.Tracef("Security descriptor '%s' found: %+v\n", resultFileName, result)
+	}
+	return result, resultFileName, nil
+}
+
+// getScopeNameWithoutPrefix returns name of scope without
+// application name prefix (e.g. $XSAPPNAME.Display -> Display)
+func getScopeNameWithoutPrefix(scope string) string {
+	if idx := strings.Index(scope, "."); idx >= 0 && idx < len(scope)-1 {
+		return scope[idx+1:]
+	}
+	return scope
+}
+
+// buildSecurityDescriptor merges security descriptor provided by user (can be nil)
+// with scopes required by applications. Scopes and role templates are added
+// only if they are not already defined by user. Other properties of user
+// security descriptor are preserved as is
+func buildSecurityDescriptor(base map[string]interface{}, xsappname string, scopes []string) map[string]interface{} {
+	securityDescriptor := map[string]interface{}{}
+	if base != nil {
+		securityDescriptor = copyJSONValue(base).(map[string]interface{})
+	}
+	if name, ok := securityDescriptor["xsappname"].(string); !ok || name == "" {
+		securityDescriptor["xsappname"] = xsappname
+	}
+	foreignScopeReferences, _ := securityDescriptor["foreign-scope-references"].([]interface{})
+	if !containsJSONString(foreignScopeReferences, "uaa.user") {
+		securityDescriptor["foreign-scope-references"] = append(foreignScopeReferences, "uaa.user")
+	}
+
+	definedScopes, _ := securityDescriptor["scopes"].([]interface{})
+	definedRoleTemplates, _ := securityDescriptor["role-templates"].([]interface{})
+	for _, scope := range scopes {
+		scopeNameWithoutPrefix := getScopeNameWithoutPrefix(scope)
+
+		// Scope
+		scopeDefined := false
+		for _, value := range definedScopes {
+			if definedScope, ok := value.(map[string]interface{}); ok && definedScope["name"] == scope {
+				scopeDefined = true
+				break
+			}
+		}
+		if !scopeDefined {
+			definedScopes = append(definedScopes, map[string]interface{}{
+				"name":        scope,
+				"description": scopeNameWithoutPrefix,
+			})
+		}
+
+		// Role template
+		roleTemplateDefined := false
+		for _, value := range definedRoleTemplates {
+			definedRoleTemplate, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			scopeReferences, _ := definedRoleTemplate["scope-references"].([]interface{})
+			if definedRoleTemplate["name"] == scopeNameWithoutPrefix || containsJSONString(scopeReferences, scope) {
+				roleTemplateDefined = true
+				break
+			}
+		}
+		if !roleTemplateDefined {
+			definedRoleTemplates = append(definedRoleTemplates, map[string]interface{}{
+				"name":             scopeNameWithoutPrefix,
+				"description":      scope,
+				"scope-references": []interface{}{scope},
+			})
+		}
+	}
+	if len(definedScopes) > 0 {
+		securityDescriptor["scopes"] = definedScopes
+	}
+	if len(definedRoleTemplates) > 0 {
+		securityDescriptor["role-templates"] = definedRoleTemplates
+	}
+
+	return securityDescriptor
+}
+
+// copyJSONValue returns deep copy of parsed JSON value
+func copyJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = copyJSONValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = copyJSONValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// containsJSONString checks if parsed JSON array contains string
+func containsJSONString(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBuildSecurityDescriptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		scopes   []string
+		expected string
+	}{
+		{
+			name:     "without base and scopes",
+			expected: `{"xsappname": "app-host-1", "foreign-scope-references": ["uaa.user"]}`,
+		},
+		{
+			name:   "without base",
+			scopes: []string{"$XSAPPNAME.Display"},
+			expected: `{
+				"xsappname": "app-host-1",
+				"foreign-scope-references": ["uaa.user"],
+				"scopes": [{"name": "$XSAPPNAME.Display", "description": "Display"}],
+				"role-templates": [{"name": "Display", "description": "$XSAPPNAME.Display", "scope-references": ["$XSAPPNAME.Display"]}]
+			}`,
+		},
+		{
+			name: "unknown properties",
+			base: `{
+				"xsappname": "my-app",
+				"tenant-mode": "shared",
+				"oauth2-configuration": {"token-validity": 900, "redirect-uris": ["https://*.example.com/**"]},
+				"foreign-scope-references": ["uaa.user", "$ACCEPT_GRANTED_SCOPES"]
+			}`,
+			expected: `{
+				"xsappname": "my-app",
+				"tenant-mode": "shared",
+				"oauth2-configuration": {"token-validity": 900, "redirect-uris": ["https://*.example.com/**"]},
+				"foreign-scope-references": ["uaa.user", "$ACCEPT_GRANTED_SCOPES"]
+			}`,
+		},
+		{
+			name:   "defined scopes and role templates",
+			base:   `{"xsappname": "", "foreign-scope-references": ["$ACCEPT_GRANTED_SCOPES"], "scopes": [{"name": "$XSAPPNAME.Display", "description": "Custom", "granted-apps": ["other"]}], "role-templates": [{"name": "Viewer", "scope-references": ["$XSAPPNAME.Display"]}, {"name": "Editor"}]}`,
+			scopes: []string{"$XSAPPNAME.Display", "$XSAPPNAME.Edit"},
+			expected: `{
+				"xsappname": "app-host-1",
+				"foreign-scope-references": ["$ACCEPT_GRANTED_SCOPES", "uaa.user"],
+				"scopes": [
+					{"name": "$XSAPPNAME.Display", "description": "Custom", "granted-apps": ["other"]},
+					{"name": "$XSAPPNAME.Edit", "description": "Edit"}
+				],
+				"role-templates": [
+					{"name": "Viewer", "scope-references": ["$XSAPPNAME.Display"]},
+					{"name": "Editor"},
+					{"name": "Edit", "description": "$XSAPPNAME.Edit", "scope-references": ["$XSAPPNAME.Edit"]}
+				]
+			}`,
+		},
+	}
+	for _, test := range tests {
+		var base map[string]interface{}
+		var err error
+		if test.base != "" {
+			base, err = parseSecurityDescriptor(test.name, []byte(test.base))
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err.Error())
+			}
+		}
+		baseCopy := copyJSONValue(base)
+
+		result := buildSecurityDescriptor(base, "app-host-1", test.scopes)
+
+		if base != nil && !reflect.DeepEqual(base, baseCopy) {
+			t.Errorf("%s: buildSecurityDescriptor changed base security descriptor: %+v", test.name, base)
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+		expected, _ := parseSecurityDescriptor(test.name, []byte(test.expected))
+		actual, _ := parseSecurityDescriptor(test.name, resultJSON)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: buildSecurityDescriptor returned %s, expected %s", test.name, resultJSON, toJSON(expected))
+		}
+	}
+}
+
+func TestParseSecurityDescriptor(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{content: `{"xsappname": "app", "oauth2-configuration": {"token-validity": 12345678901234567890}}`, expected: `{"oauth2-configuration":{"token-validity":12345678901234567890},"xsappname":"app"}`},
+		{content: `{}`, expected: `{}`},
+		{content: `null`},
+		{content: `[]`},
+		{content: `{"xsappname": `},
+	}
+	for _, test := range tests {
+		securityDescriptor, err := parseSecurityDescriptor("xs-security.json", []byte(test.content))
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("parseSecurityDescriptor(%s) should fail", test.content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSecurityDescriptor(%s) returned error: %s", test.content, err.Error())
+		} else if result := toJSON(securityDescriptor); result != test.expected {
+			t.Errorf("parseSecurityDescriptor(%s) = %s, expected %s", test.content, result, test.expected)
+		}
+	}
+}
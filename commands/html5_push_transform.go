@@ -0,0 +1,508 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Files, in which placeholders are always substituted
+var placeholderFileNames = []string{"manifest.json", "xs-app.json"}
+
+// Placeholder of variable value (e.g. ${DESTINATION_NAME})
+var placeholderRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// unresolvedPlaceholder placeholder without variable value
+type unresolvedPlaceholder struct {
+	// Name of variable
+	Name string
+	// Line number in file
+	Line int
+}
+
+// appTransformer changes content of application files written into archive
+// pushed to app-host, leaving the source files untouched
+type appTransformer struct {
+	// Value of sap.app/applicationVersion/version in manifest.json
+	Version string
+	// Suffix appended to sap.app/applicationVersion/version in manifest.json
+	VersionSuffix string
+	// Value of sap.cloud/service in manifest.json
+	Service string
+	// Values of variables substituted for ${VAR} placeholders
+	Vars map[string]string
+	// Substitute placeholders with values of environment variables
+	VarsEnv bool
+	// Additional files, in which placeholders are substituted
+	VarsFiles *ignoreMatcher
+}
+
+// Active checks if transformer changes any file.
+// Transformer can be nil, in which case nothing is changed
+func (t *appTransformer) Active() bool {
+	return t != nil && (t.overridesManifest() || t.substitutesVariables())
+}
+
+// overridesManifest checks if transformer overrides values of manifest.json
+func (t *appTransformer) overridesManifest() bool {
+	return t.Version != "" || t.VersionSuffix != "" || t.Service != ""
+}
+
+// substitutesVariables checks if transformer substitutes placeholders
+func (t *appTransformer) substitutesVariables() bool {
+	return t.Vars != nil || t.VarsEnv
+}
+
+// Applies checks if transformer changes file with given path
+// relative to application root
+func (t *appTransformer) Applies(name string) bool {
+	if !t.Active() || strings.HasSuffix(name, "/") {
+		return false
+	}
+	if name == "manifest.json" && t.overridesManifest() {
+		return true
+	}
+	if !t.substitutesVariables() {
+		return false
+	}
+	return indexOfString(placeholderFileNames, name) >= 0 || t.VarsFiles.Ignored(name, false)
+}
+
+// Transform returns changed content of file with given path
+// relative to application root
+func (t *appTransformer) Transform(name string, content []byte) ([]byte, error) {
+	if !t.Applies(name) {
+		return content, nil
+	}
+	if t.substitutesVariables() {
+		var unresolved []unresolvedPlaceholder
+		content, unresolved = t.substituteVariables(name, content)
+		if len(unresolved) > 0 {
+			return nil, fmt.Errorf("Unresolved placeholder ${%s} in %s at line %d", unresolved[0].Name, name, unresolved[0].Line)
+		}
+	}
+	if name == "manifest.json" && t.overridesManifest() {
+		return t.transformManifest(content)
+	}
+	return content, nil
+}
+
+// substituteVariables replaces ${VAR} placeholders with values of variables.
+// Values are escaped as JSON string content in files with .json extension.
+// Placeholders without values are kept and returned as unresolved
+func (t *appTransformer) substituteVariables(name string, content []byte) ([]byte, []unresolvedPlaceholder) {
+	escape := strings.HasSuffix(strings.ToLower(name), ".json")
+	unresolved := make([]unresolvedPlaceholder, 0)
+	lines := bytes.Split(content, []byte("\n"))
+	for idx, line := range lines {
+		lines[idx] = placeholderRegexp.ReplaceAllFunc(line, func(placeholder []byte) []byte {
+			name := string(placeholder[2 : len(placeholder)-1])
+			value, ok := t.lookupVariable(name)
+			if !ok {
+				unresolved = append(unresolved, unresolvedPlaceholder{Name: name, Line: idx + 1})
+				return placeholder
+			}
+			if escape {
+				quoted := marshalJSONString(value)
+				return quoted[1 : len(quoted)-1]
+			}
+			return []byte(value)
+		})
+	}
+	return bytes.Join(lines, []byte("\n")), unresolved
+}
+
+// lookupVariable returns value of variable from values file, or
+// from environment variables, if enabled
+func (t *appTransformer) lookupVariable(name string) (string, bool) {
+	if value, ok := t.Vars[name]; ok {
+		return value, true
+	}
+	if t.VarsEnv {
+		return os.LookupEnv(name)
+	}
+	return "", false
+}
+
+// Validate returns unresolved placeholders in files of application
+// directory or archive, which would be changed by transformer
+func (t *appTransformer) Validate(appPath string) []ValidationProblem {
+	problems := make([]ValidationProblem, 0)
+	if t == nil || !t.substitutesVariables() {
+		return problems
+	}
+	report := func(name string, content []byte) {
+		_, unresolved := t.substituteVariables(name, content)
+		for _, placeholder := range unresolved {
+			problems = append(problems, ValidationProblem{
+				File:    appPath + slash + filepath.FromSlash(name),
+				Path:    fmt.Sprintf("line %d", placeholder.Line),
+				Message: fmt.Sprintf("Unresolved placeholder ${%s}", placeholder.Name),
+			})
+		}
+	}
+
+	// Application archive
+	if isAppArchive(appPath) {
+		archive, err := zip.OpenReader(appPath)
+		if err != nil {
+			problems = append(problems, ValidationProblem{File: appPath, Message: fmt.Sprintf("Could not read archive: %s", err.Error())})
+			return problems
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			if !t.Applies(file.Name) {
+				continue
+			}
+			reader, err := file.Open()
+			if err != nil {
+				problems = append(problems, ValidationProblem{File: appPath + slash + file.Name, Message: fmt.Sprintf("Could not read file: %s", err.Error())})
+				continue
+			}
+			content, err := ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				problems = append(problems, ValidationProblem{File: appPath + slash + file.Name, Message: fmt.Sprintf("Could not read file: %s", err.Error())})
+				continue
+			}
+			report(file.Name, content)
+		}
+		return problems
+	}
+
+	// Application directory
+	ignore, err := loadIgnoreFile(appPath)
+	if err != nil {
+		problems = append(problems, ValidationProblem{File: appPath, Message: fmt.Sprintf("Could not read ignore file: %s", err.Error())})
+		return problems
+	}
+	err = filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(appPath, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if ignore.Ignored(name, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !t.Applies(name) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		report(name, content)
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, ValidationProblem{File: appPath, Message: fmt.Sprintf("Could not read application directory: %s", err.Error())})
+	}
+	return problems
+}
+
+// transformManifest overrides version and business service of manifest.json.
+// Only changed values are replaced, the rest of the file is kept as is
+func (t *appTransformer) transformManifest(content []byte) ([]byte, error) {
+	var manifest map[string]interface{}
+	err := json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse manifest.json: %s", err.Error())
+	}
+
+	// Version
+	if t.Version != "" || t.VersionSuffix != "" {
+		versionPath := []string{"sap.app", "applicationVersion", "version"}
+		version := t.Version
+		if version == "" {
+			start, end, depth, _, err := findJSONValue(content, versionPath)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse manifest.json: %s", err.Error())
+			}
+			if depth == len(versionPath) {
+				json.Unmarshal(content[start:end], &version)
+			}
+		}
+		version += t.VersionSuffix
+		content, err = setJSONValue(content, versionPath, version)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse manifest.json: %s", err.Error())
+		}
+		log.Tracef("Overriding sap.app/applicationVersion/version with '%s'\n", version)
+	}
+
+	// Business service
+	if t.Service != "" {
+		content, err = setJSONValue(content, []string{"sap.cloud", "service"}, t.Service)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse manifest.json: %s", err.Error())
+		}
+		log.Tracef("Overriding sap.cloud/service with '%s'\n", t.Service)
+	}
+
+	return content, nil
+}
+
+// setJSONValue replaces string value with given path of JSON object document,
+// creating missing objects on the path, without changing the rest of document
+func setJSONValue(content []byte, path []string, value string) ([]byte, error) {
+	start, end, depth, isObject, err := findJSONValue(content, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Value of missing objects on the path, e.g. {"c": "value"}
+	replacement := marshalJSONString(value)
+	for idx := len(path) - 1; idx > depth; idx-- {
+		replacement = append(append([]byte("{"), jsonProperty(path[idx], replacement)...), '}')
+	}
+
+	result := make([]byte, 0, len(content)+len(replacement))
+	if depth < len(path) && isObject {
+		// Add property to existing object
+		property := jsonProperty(path[depth], replacement)
+		if len(bytes.TrimSpace(content[start+1:end-1])) > 0 {
+			property = append(property, ", "...)
+		}
+		result = append(result, content[:start+1]...)
+		result = append(result, property...)
+		return append(result, content[start+1:]...), nil
+	}
+
+	// Replace existing value
+	if depth < len(path) {
+		replacement = append(append([]byte("{"), jsonProperty(path[depth], replacement)...), '}')
+	}
+	result = append(result, content[:start]...)
+	result = append(result, replacement...)
+	return append(result, content[end:]...), nil
+}
+
+// jsonProperty returns JSON object property with given name and value
+func jsonProperty(name string, value []byte) []byte {
+	return append(append(marshalJSONString(name), ": "...), value...)
+}
+
+// findJSONValue looks for value with given path in JSON object document.
+// Returns offsets of the deepest value found on the path, number of path
+// elements matched and whether the found value is an object
+func findJSONValue(content []byte, path []string) (int, int, int, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	start := skipJSONSeparators(content, 0)
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	if token != json.Delim('{') {
+		return 0, 0, 0, false, fmt.Errorf("JSON object expected")
+	}
+	for depth := 0; ; {
+		found := false
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return 0, 0, 0, false, err
+			}
+			key, _ := token.(string)
+			valueStart := skipJSONSeparators(content, int(decoder.InputOffset()))
+			token, err = decoder.Token()
+			if err != nil {
+				return 0, 0, 0, false, err
+			}
+			if key != path[depth] {
+				err = skipJSONValue(decoder, token)
+				if err != nil {
+					return 0, 0, 0, false, err
+				}
+				continue
+			}
+			start = valueStart
+			depth++
+			if token != json.Delim('{') || depth == len(path) {
+				err = skipJSONValue(decoder, token)
+				if err != nil {
+					return 0, 0, 0, false, err
+				}
+				return start, int(decoder.InputOffset()), depth, token == json.Delim('{'), nil
+			}
+			found = true
+			break
+		}
+		if found {
+			continue
+		}
+		// End of object without matching property
+		_, err = decoder.Token()
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		return start, int(decoder.InputOffset()), depth, true, nil
+	}
+}
+
+// skipJSONValue reads the rest of object or array started by token
+func skipJSONValue(decoder *json.Decoder, token json.Token) error {
+	if token != json.Delim('{') && token != json.Delim('[') {
+		return nil
+	}
+	for level := 1; level > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			level++
+		case json.Delim('}'), json.Delim(']'):
+			level--
+		}
+	}
+	return nil
+}
+
+// skipJSONSeparators returns offset of the next JSON token
+// skipping whitespace, colons and commas
+func skipJSONSeparators(content []byte, offset int) int {
+	for offset < len(content) && strings.IndexByte(" \t\r\n:,", content[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// marshalJSONString returns quoted JSON string without escaping HTML characters
+func marshalJSONString(value string) []byte {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return bytes.TrimRight(buffer.Bytes(), "\n")
+}
+
+// readTransformedAppFile reads file from application directory
+// or archive and applies transformer to its content
+func readTransformedAppFile(appPath string, fileName string, transform *appTransformer) ([]byte, error) {
+	content, err := readAppFile(appPath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return transform.Transform(fileName, content)
+}
+
+// transformAppArchive copies application archive applying transformer
+// to its files
+func transformAppArchive(archivePath string, zipPath string, transform *appTransformer) error {
+	log.Tracef("Transforming application archive '%s' to '%s'\n", archivePath, zipPath)
+
+	source, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("Could not read application archive '%s' : %+v", archivePath, err)
+	}
+	defer source.Close()
+
+	zipfile, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zipfile.Close()
+
+	archive := zip.NewWriter(zipfile)
+	defer archive.Close()
+
+	for _, file := range source.File {
+		if !transform.Applies(file.Name) {
+			err = archive.Copy(file)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		content, err = transform.Transform(file.Name, content)
+		if err != nil {
+			return fmt.Errorf("Could not transform %s of application archive '%s' : %+v", file.Name, archivePath, err)
+		}
+		header := file.FileHeader
+		header.Method = zip.Deflate
+		writer, err := archive.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadVariables reads values of variables from JSON or YAML file.
+// Values should be strings, numbers or booleans
+func loadVariables(fileName string) (map[string]string, error) {
+	var values map[string]interface{}
+
+	fileContents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read values file '%s': %s", fileName, err.Error())
+	}
+	log.Tracef("Reading values file '%s'\n", fileName)
+	err = yaml.Unmarshal(fileContents, &values)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse values file '%s': %s", fileName, err.Error())
+	}
+
+	vars := make(map[string]string)
+	for name, value := range values {
+		switch value.(type) {
+		case string, int, int64, float64, bool:
+			vars[name] = fmt.Sprint(value)
+		case nil:
+			vars[name] = ""
+		default:
+			return nil, fmt.Errorf("Value of variable '%s' in values file '%s' should be a string, number or boolean", name, fileName)
+		}
+	}
+	log.Tracef("Variables: %v\n", log.Sensitive{Data: vars})
+
+	return vars, nil
+}
+
+// parseVarsGlobs converts glob patterns (.gitignore syntax) of files,
+// in which placeholders are substituted, to matcher
+func parseVarsGlobs(globs []string) (*ignoreMatcher, error) {
+	if len(globs) == 0 {
+		return nil, nil
+	}
+	matcher := &ignoreMatcher{FileName: "--vars-glob", patterns: make([]ignorePattern, 0), dirs: make(map[string]bool)}
+	for _, glob := range globs {
+		pattern, ok := parseIgnorePattern(glob)
+		if !ok {
+			return nil, fmt.Errorf("Invalid glob pattern '%s'", glob)
+		}
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+	return matcher, nil
+}
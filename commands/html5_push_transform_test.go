@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTransformManifest(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform appTransformer
+		manifest  string
+		expected  string
+		fails     bool
+	}{
+		{
+			name:      "version",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  "{\n  \"sap.app\": {\n    \"id\": \"app\",\n    \"applicationVersion\": {\n      \"version\": \"1.0.0\"\n    }\n  }\n}\n",
+			expected:  "{\n  \"sap.app\": {\n    \"id\": \"app\",\n    \"applicationVersion\": {\n      \"version\": \"2.0.0\"\n    }\n  }\n}\n",
+		},
+		{
+			name:      "version suffix",
+			transform: appTransformer{VersionSuffix: "-build.1"},
+			manifest:  `{"sap.app": {"applicationVersion": {"version" : "1.0.0"}}}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version" : "1.0.0-build.1"}}}`,
+		},
+		{
+			name:      "version and suffix",
+			transform: appTransformer{Version: "2.0.0", VersionSuffix: "-rc"},
+			manifest:  `{"sap.app": {"applicationVersion": {"version": "1.0.0"}}}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version": "2.0.0-rc"}}}`,
+		},
+		{
+			name:      "other values are kept as is",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `{"z": "<b>&</b>", "sap.app": {"n": 12345678901234567890, "f": 1.50, "applicationVersion": {"version": "1.0.0"}, "a": [{"version": "x"}]}, "version": "x"}`,
+			expected:  `{"z": "<b>&</b>", "sap.app": {"n": 12345678901234567890, "f": 1.50, "applicationVersion": {"version": "2.0.0"}, "a": [{"version": "x"}]}, "version": "x"}`,
+		},
+		{
+			name:      "missing version",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `{"sap.app": {"applicationVersion": {}}}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version": "2.0.0"}}}`,
+		},
+		{
+			name:      "missing objects",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `{"_version": "1.1.0"}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version": "2.0.0"}}, "_version": "1.1.0"}`,
+		},
+		{
+			name:      "non-object value",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `{"sap.app": {"applicationVersion": "1.0.0"}}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version": "2.0.0"}}}`,
+		},
+		{
+			name:      "service",
+			transform: appTransformer{Service: "my.service"},
+			manifest:  `{"sap.cloud": {"public": true, "service": "old.service"}}`,
+			expected:  `{"sap.cloud": {"public": true, "service": "my.service"}}`,
+		},
+		{
+			name:      "missing service",
+			transform: appTransformer{Service: "my.service"},
+			manifest:  `{"sap.cloud": {"public": true}}`,
+			expected:  `{"sap.cloud": {"service": "my.service", "public": true}}`,
+		},
+		{
+			name:      "escaped values",
+			transform: appTransformer{Version: "1.0.0-\"<x>\""},
+			manifest:  `{"sap.app": {"applicationVersion": {"version": "1.0.0"}}}`,
+			expected:  `{"sap.app": {"applicationVersion": {"version": "1.0.0-\"<x>\""}}}`,
+		},
+		{
+			name:      "invalid JSON",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `{"sap.app": {"applicationVersion": {"version": "1.0.0"}}`,
+			fails:     true,
+		},
+		{
+			name:      "not an object",
+			transform: appTransformer{Version: "2.0.0"},
+			manifest:  `["sap.app"]`,
+			fails:     true,
+		},
+	}
+	for _, test := range tests {
+		result, err := test.transform.transformManifest([]byte(test.manifest))
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: transformManifest should fail, returned %s", test.name, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: transformManifest returned error: %s", test.name, err.Error())
+		} else if string(result) != test.expected {
+			t.Errorf("%s: transformManifest returned\n%s\nexpected\n%s", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestAppTransformerApplies(t *testing.T) {
+	var inactive *appTransformer
+	if inactive.Active() {
+		t.Errorf("nil transformer should not be active")
+	}
+	transform := &appTransformer{VersionSuffix: "-1"}
+	tests := map[string]bool{
+		"manifest.json":         true,
+		"xs-app.json":           false,
+		"webapp/manifest.json":  false,
+		"manifest.json/":        false,
+		"webapp/index.html":     false,
+		"xs-security.json":      false,
+		"webapp/i18n/i18n.json": false,
+	}
+	for name, expected := range tests {
+		if applies := transform.Applies(name); applies != expected {
+			t.Errorf("Applies(%q) = %v, expected %v", name, applies, expected)
+		}
+	}
+}
+
+func TestSubstituteVariables(t *testing.T) {
+	os.Setenv("HTML5_TRANSFORM_TEST_ENV", "from-env")
+	defer os.Unsetenv("HTML5_TRANSFORM_TEST_ENV")
+
+	vars := map[string]string{
+		"DESTINATION": "my-destination",
+		"QUOTED":      "a \"b\" <c> & d\\e",
+		"my.var-1":    "dotted",
+		"EMPTY":       "",
+	}
+	tests := []struct {
+		name       string
+		fileName   string
+		transform  appTransformer
+		content    string
+		expected   string
+		unresolved []unresolvedPlaceholder
+	}{
+		{
+			name:      "values",
+			fileName:  "xs-app.json",
+			transform: appTransformer{Vars: vars},
+			content:   `{"destination": "${DESTINATION}", "a": "${my.var-1}${EMPTY}"}`,
+			expected:  `{"destination": "my-destination", "a": "dotted"}`,
+		},
+		{
+			name:      "escaped in JSON",
+			fileName:  "webapp/i18n/texts.JSON",
+			transform: appTransformer{Vars: vars},
+			content:   `{"text": "${QUOTED}"}`,
+			expected:  `{"text": "a \"b\" <c> & d\\e"}`,
+		},
+		{
+			name:      "as is in other files",
+			fileName:  "index.html",
+			transform: appTransformer{Vars: vars},
+			content:   `<p>${QUOTED}</p>`,
+			expected:  `<p>a "b" <c> & d\e</p>`,
+		},
+		{
+			name:      "not placeholders",
+			fileName:  "index.html",
+			transform: appTransformer{Vars: vars},
+			content:   "$DESTINATION ${} ${1A} $${DESTINATION",
+			expected:  "$DESTINATION ${} ${1A} $${DESTINATION",
+		},
+		{
+			name:      "environment variables",
+			fileName:  "xs-app.json",
+			transform: appTransformer{Vars: vars, VarsEnv: true},
+			content:   `"${DESTINATION}" "${HTML5_TRANSFORM_TEST_ENV}"`,
+			expected:  `"my-destination" "from-env"`,
+		},
+		{
+			name:      "environment variables disabled",
+			fileName:  "xs-app.json",
+			transform: appTransformer{Vars: vars},
+			content:   "{\n  \"a\": \"${DESTINATION}\",\n  \"b\": \"${HTML5_TRANSFORM_TEST_ENV}\", \"c\": \"${MISSING}\"\n}",
+			expected:  "{\n  \"a\": \"my-destination\",\n  \"b\": \"${HTML5_TRANSFORM_TEST_ENV}\", \"c\": \"${MISSING}\"\n}",
+			unresolved: []unresolvedPlaceholder{
+				{Name: "HTML5_TRANSFORM_TEST_ENV", Line: 3},
+				{Name: "MISSING", Line: 3},
+			},
+		},
+	}
+	for _, test := range tests {
+		result, unresolved := test.transform.substituteVariables(test.fileName, []byte(test.content))
+		if string(result) != test.expected {
+			t.Errorf("%s: substituteVariables returned %s, expected %s", test.name, result, test.expected)
+		}
+		if test.unresolved == nil {
+			test.unresolved = []unresolvedPlaceholder{}
+		}
+		if !reflect.DeepEqual(unresolved, test.unresolved) {
+			t.Errorf("%s: unresolved placeholders are %+v, expected %+v", test.name, unresolved, test.unresolved)
+		}
+	}
+}
+
+func TestParseVarsGlobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		globs      []string
+		fails      bool
+		matches    []string
+		mismatches []string
+	}{
+		{
+			name:       "no globs",
+			mismatches: []string{"index.html"},
+		},
+		{
+			name:       "globs",
+			globs:      []string{"*.html", "/webapp/config/**", "!webapp/config/static.json"},
+			matches:    []string{"index.html", "webapp/index.html", "webapp/config/a.json", "webapp/config/b/c.js"},
+			mismatches: []string{"index.js", "config/a.json", "webapp/config/static.json"},
+		},
+		{
+			name:  "empty glob",
+			globs: []string{"*.html", ""},
+			fails: true,
+		},
+		{
+			name:  "comment",
+			globs: []string{"# all"},
+			fails: true,
+		},
+	}
+	for _, test := range tests {
+		matcher, err := parseVarsGlobs(test.globs)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: parseVarsGlobs returned error %v, expected failure = %v", test.name, err, test.fails)
+			continue
+		}
+		for _, name := range test.matches {
+			if !matcher.Ignored(name, false) {
+				t.Errorf("%s: %s should match", test.name, name)
+			}
+		}
+		for _, name := range test.mismatches {
+			if matcher.Ignored(name, false) {
+				t.Errorf("%s: %s should not match", test.name, name)
+			}
+		}
+	}
+
+	matcher, _ := parseVarsGlobs([]string{"*.properties"})
+	transform := &appTransformer{Vars: map[string]string{}, VarsFiles: matcher}
+	for name, expected := range map[string]bool{
+		"manifest.json":                  true,
+		"xs-app.json":                    true,
+		"i18n/i18n.properties":           true,
+		"index.html":                     false,
+		"webapp/xs-app.json":             false,
+		"webapp/i18n/i18n_de.properties": true,
+	} {
+		if applies := transform.Applies(name); applies != expected {
+			t.Errorf("Applies(%q) = %v, expected %v", name, applies, expected)
+		}
+	}
+}
+
+func TestLoadVariables(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{
+			name:     "YAML",
+			content:  "DESTINATION: my-destination\nPORT: 8080\nRATIO: 1.5\nENABLED: true\nEMPTY:\n",
+			expected: map[string]string{"DESTINATION": "my-destination", "PORT": "8080", "RATIO": "1.5", "ENABLED": "true", "EMPTY": ""},
+		},
+		{
+			name:     "JSON",
+			content:  `{"DESTINATION": "my-destination", "PORT": 8080}`,
+			expected: map[string]string{"DESTINATION": "my-destination", "PORT": "8080"},
+		},
+		{
+			name:    "object value",
+			content: "DESTINATION:\n  name: my-destination\n",
+		},
+		{
+			name:    "invalid",
+			content: "DESTINATION: [",
+		},
+	}
+	dir, err := ioutil.TempDir("", "html5-transform-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for idx, test := range tests {
+		fileName := filepath.Join(dir, "vars"+string(rune('0'+idx))+".yaml")
+		if err = ioutil.WriteFile(fileName, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		vars, err := loadVariables(fileName)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("%s: loadVariables should fail, returned %v", test.name, vars)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: loadVariables returned error: %s", test.name, err.Error())
+		} else if !reflect.DeepEqual(vars, test.expected) {
+			t.Errorf("%s: loadVariables returned %v, expected %v", test.name, vars, test.expected)
+		}
+	}
+	if _, err = loadVariables(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("loadVariables of missing file should fail")
+	}
+}
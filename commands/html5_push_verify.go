@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VerificationProblem missing or mismatched file of pushed application
+type VerificationProblem struct {
+	// Key of application (name-version)
+	AppKey string
+	// Path of file relative to application root
+	Path string
+	// Description of problem
+	Message string
+}
+
+// verifyPushedApp compares paths and sizes of files deployed to app-host
+// with files of application archive, which was uploaded
+func verifyPushedApp(html5Context HTML5Context, appHostGUID string, appKey string, zipFile string) ([]VerificationProblem, int, error) {
+	problems := make([]VerificationProblem, 0)
+
+	// Local files
+	localSize, err := getArchiveSize(zipFile)
+	if err != nil {
+		return problems, 0, fmt.Errorf("Could not read archive '%s': %+v", zipFile, err)
+	}
+	localFiles := make(map[string]int)
+	for _, file := range localSize.Files {
+		localFiles[file.Path] = file.Size
+	}
+
+	// Deployed files
+	log.Tracef("Verifying files of application %s\n", appKey)
+	deployedFiles, err := getDeployedAppFiles(html5Context, appHostGUID, appKey)
+	if err != nil {
+		return problems, 0, err
+	}
+	deployed := make(map[string]bool)
+	for _, deployedFile := range deployedFiles {
+		path := strings.TrimPrefix(deployedFile.FilePath, "/")
+		path = strings.TrimPrefix(path, appKey+"/")
+		deployed[path] = true
+		localFileSize, ok := localFiles[path]
+		if !ok {
+			problems = append(problems, VerificationProblem{AppKey: appKey, Path: path, Message: "Deployed file does not exist in uploaded archive"})
+			continue
+		}
+		if localFileSize != deployedFile.FileMetadata.FileSize {
+			problems = append(problems, VerificationProblem{AppKey: appKey, Path: path,
+				Message: fmt.Sprintf("Deployed file has size %d, uploaded file has size %d", deployedFile.FileMetadata.FileSize, localFileSize)})
+		}
+	}
+	for path := range localFiles {
+		if !deployed[path] {
+			problems = append(problems, VerificationProblem{AppKey: appKey, Path: path, Message: "Uploaded file is missing in app-host"})
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	log.Tracef("Verification problems of application %s: %+v\n", appKey, problems)
+
+	return problems, len(localFiles), nil
+}
+
+// printVerificationProblems prints table of verification problems
+func printVerificationProblems(problems []VerificationProblem) {
+	table := ui.Table([]string{"application", "file", "problem"})
+	for _, problem := range problems {
+		table.Add(problem.AppKey, problem.Path, problem.Message)
+	}
+	table.Print()
+	ui.Say("")
+}
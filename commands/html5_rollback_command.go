@@ -0,0 +1,315 @@
+package commands
+
+import (
+	clients "cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// RollbackCommand restore content of app-host service instance
+// from local snapshot created by html5-push
+type RollbackCommand struct {
+	HTML5Command
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *RollbackCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "html5-rollback",
+		HelpText: "Restore content of app-host service instance from snapshot created before push",
+		UsageDetails: plugin.Usage{
+			Usage: "cf html5-rollback APP_HOST_ID|-n APP_HOST_NAME [--to SNAPSHOT] [--list]",
+			Options: map[string]string{
+				"-to":           "Identifier of snapshot to restore. Default is the newest snapshot",
+				"-list":         "List snapshots of app-host service instance without restoring them",
+				"-name,-n":      "Use app-host service instance with specified name",
+				"APP_HOST_ID":   "GUID of html5-apps-repo app-host service instance",
+				"APP_HOST_NAME": "Name of html5-apps-repo app-host service instance",
+			},
+		},
+	}
+}
+
+// Execute executes plugin command
+func (c *RollbackCommand) Execute(args []string) ExecutionStatus {
+	log.Tracef("Executing command '%s': args: '%v'\n", c.Name, args)
+
+	flagSet := flag.NewFlagSet("html5-rollback", flag.ContinueOnError)
+	toFlag := flagSet.String("to", "", "snapshot to restore")
+	listFlag := flagSet.Bool("list", false, "list snapshots")
+	nameFlag := flagSet.String("name", "", "app-host service instance name")
+	nameFlagAlias := flagSet.String("n", "", "app-host service instance name")
+	err := flagSet.Parse(args)
+	if err != nil {
+		ui.Failed("Could not parse arguments: %+v", err)
+		return Failure
+	}
+
+	// Normalize aliases
+	appHostName := *nameFlagAlias
+	if *nameFlag != "" {
+		appHostName = *nameFlag
+	}
+
+	if *listFlag && *toFlag != "" {
+		ui.Failed("Options --list and --to are mutually exclusive")
+		return Failure
+	}
+
+	if appHostName != "" && flagSet.NArg() == 0 {
+		if *listFlag {
+			return c.ListSnapshots(appHostName, true)
+		}
+		return c.RollbackAppHost(appHostName, true, *toFlag)
+	}
+	if appHostName == "" && flagSet.NArg() == 1 {
+		if *listFlag {
+			return c.ListSnapshots(flagSet.Arg(0), false)
+		}
+		return c.RollbackAppHost(flagSet.Arg(0), false, *toFlag)
+	}
+
+	ui.Failed("Incorrect number of arguments passed. See [cf html5-rollback --help] for more details")
+	return Failure
+}
+
+// ListSnapshots print snapshots of app-host with results of integrity check
+func (c *RollbackCommand) ListSnapshots(appHostNameOrGUID string, isName bool) ExecutionStatus {
+	log.Tracef("Listing snapshots of app-host '%s'\n", appHostNameOrGUID)
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	ui.Say("Getting snapshots of app-host %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(appHostNameOrGUID),
+		terminal.EntityNameColor(context.Org),
+		terminal.EntityNameColor(context.Space),
+		terminal.EntityNameColor(context.Username))
+
+	appHostGUID := appHostNameOrGUID
+	if isName {
+		// Resolve app-host-id
+		log.Tracef("Resolving app-host-id by service instance name '%s'\n", appHostNameOrGUID)
+		serviceInstance, err := clients.GetServiceInstanceByName(c.CliConnection, context.SpaceID, appHostNameOrGUID)
+		if err != nil {
+			ui.Failed("%+v", err)
+			return Failure
+		}
+		log.Tracef("Resolved app-host-id is '%s'\n", serviceInstance.GUID)
+		appHostGUID = serviceInstance.GUID
+	}
+
+	// Load snapshots
+	snapshots, err := loadSnapshots(appHostGUID)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	ui.Ok()
+	ui.Say("")
+
+	if len(snapshots) == 0 {
+		ui.Say("No snapshots of app-host %s found in %s", appHostGUID, getSnapshotsDir(appHostGUID))
+		ui.Say("")
+		return Success
+	}
+
+	table := ui.Table([]string{"snapshot", "created on", "applications", "size", "integrity"})
+	for _, snapshot := range snapshots {
+		integrity := "ok"
+		if err := verifySnapshot(snapshot); err != nil {
+			log.Tracef("%+v\n", err)
+			integrity = terminal.FailureColor("corrupted")
+		}
+		table.Add(snapshot.ID,
+			snapshot.CreatedOn.Local().Format("Mon Jan 02 15:04:05 MST 2006"),
+			strconv.Itoa(len(snapshot.Apps)),
+			getReadableSize(int(snapshot.Size())),
+			integrity)
+	}
+	table.Print()
+	ui.Say("")
+
+	return Success
+}
+
+// RollbackAppHost replace content of app-host with applications from snapshot
+func (c *RollbackCommand) RollbackAppHost(appHostNameOrGUID string, isName bool, snapshotID string) ExecutionStatus {
+	log.Tracef("Rolling back app-host '%s' to snapshot '%s'\n", appHostNameOrGUID, snapshotID)
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	ui.Say("Rolling back app-host %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(appHostNameOrGUID),
+		terminal.EntityNameColor(context.Org),
+		terminal.EntityNameColor(context.Space),
+		terminal.EntityNameColor(context.Username))
+
+	appHostGUID := appHostNameOrGUID
+	if isName {
+		// Resolve app-host-id
+		log.Tracef("Resolving app-host-id by service instance name '%s'\n", appHostNameOrGUID)
+		serviceInstance, err := clients.GetServiceInstanceByName(c.CliConnection, context.SpaceID, appHostNameOrGUID)
+		if err != nil {
+			ui.Failed("%+v", err)
+			return Failure
+		}
+		log.Tracef("Resolved app-host-id is '%s'\n", serviceInstance.GUID)
+		appHostGUID = serviceInstance.GUID
+	}
+
+	// Find snapshot
+	snapshots, err := loadSnapshots(appHostGUID)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	if len(snapshots) == 0 {
+		ui.Failed("No snapshots of app-host %s found in %s", appHostGUID, getSnapshotsDir(appHostGUID))
+		return Failure
+	}
+	var snapshot *Snapshot
+	if snapshotID == "" {
+		snapshot = &snapshots[0]
+	} else {
+		for idx := range snapshots {
+			if snapshots[idx].ID == snapshotID {
+				snapshot = &snapshots[idx]
+				break
+			}
+		}
+		if snapshot == nil {
+			ui.Failed("Snapshot %s of app-host %s not found. See [cf html5-rollback %s --list] for available snapshots",
+				snapshotID, appHostGUID, appHostGUID)
+			return Failure
+		}
+	}
+	log.Tracef("Using snapshot %+v\n", *snapshot)
+
+	// Check integrity of snapshot before deleting anything
+	err = verifySnapshot(*snapshot)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Get HTML5 context
+	html5Context, err := c.GetHTML5Context(context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Snapshot current content, so rollback can be undone
+	currentSnapshot, err := createSnapshot(html5Context, appHostGUID)
+	if err != nil {
+		ui.Failed("Could not create snapshot of app-host-id '%s' : %+v", appHostGUID, err)
+		return Failure
+	}
+
+	// Create service key for DT
+	log.Tracef("Creating service key for app-host-id '%s'\n", appHostGUID)
+	serviceKey, err := clients.CreateServiceKey(c.CliConnection, appHostGUID, nil)
+	if err != nil {
+		ui.Failed("Could not create service key for service instance with id '%s' : %+v", appHostGUID, err)
+		return Failure
+	}
+	serviceKeyDeleted := false
+	defer func() {
+		if serviceKeyDeleted {
+			return
+		}
+		log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+		err := clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+		if err != nil {
+			ui.Warn("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+		}
+	}()
+
+	// Hint how to restore content of app-host, if rollback fails
+	restoreHint := ""
+	if currentSnapshot != nil {
+		restoreHint = fmt.Sprintf(". Previous content of app-host is saved as snapshot %s and can be restored with 'cf html5-rollback %s --to %s'",
+			currentSnapshot.ID, appHostGUID, currentSnapshot.ID)
+	}
+
+	// Obtain access token
+	log.Tracef("Obtaining access token for service key '%s'\n", serviceKey.Name)
+	token, err := clients.GetToken(serviceKey.Credentials)
+	if err != nil {
+		ui.Failed("Could not obtain access token for service key '%s': %+v", serviceKey.Name, err)
+		return Failure
+	}
+
+	// Delete content of app-host
+	log.Tracef("Deleting content of app-host-id '%s'\n", appHostGUID)
+	err = clients.DeleteServiceContent(*serviceKey.Credentials.URI, token)
+	if err != nil {
+		ui.Failed("Could not delete content of app-host-id '%s': %+v%s", appHostGUID, err, restoreHint)
+		return Failure
+	}
+
+	// Upload applications of snapshot
+	err = clients.UploadAppHost(*serviceKey.Credentials.URI, snapshot.ZipFiles(), token, newUploadProgressReporter())
+	if err != nil {
+		ui.Failed("Could not upload applications of snapshot %s to app-host-id '%s': %+v%s", snapshot.ID, appHostGUID, err, restoreHint)
+		return Failure
+	}
+
+	// Apply retention limit
+	err = pruneSnapshots(appHostGUID, getSnapshotsKeep())
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Delete temporarry service keys
+	log.Tracef("Deleting temporarry service key: '%s'\n", serviceKey.Name)
+	err = clients.DeleteServiceKey(c.CliConnection, serviceKey.GUID, maxRetryCount)
+	serviceKeyDeleted = true
+	if err != nil {
+		ui.Failed("Could not delete service key '%s' : %+v", serviceKey.Name, err)
+		return Failure
+	}
+
+	// Clean-up HTML5 context
+	err = c.CleanHTML5Context(html5Context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	ui.Ok()
+	ui.Say("")
+	table := ui.Table([]string{"name", "version", "default"})
+	for _, app := range snapshot.Apps {
+		table.Add(app.Name, app.Version, (map[bool]string{true: "yes", false: "no"})[app.IsDefault])
+	}
+	table.Print()
+	ui.Say("")
+	ui.Say("Restored %d applications from snapshot %s", len(snapshot.Apps), terminal.EntityNameColor(snapshot.ID))
+	if currentSnapshot != nil {
+		ui.Say("Previous content of app-host is saved as snapshot %s", terminal.EntityNameColor(currentSnapshot.ID))
+	}
+	ui.Say("")
+
+	return Success
+}
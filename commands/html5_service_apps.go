@@ -0,0 +1,231 @@
+package commands
+
+import (
+	clients "cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ServiceAppHost app-host of business service, which exposes
+// HTML5 applications via destination or CF application service binding
+type ServiceAppHost struct {
+	// Name of business service
+	ServiceName string
+	// Business service prefix of application key in URLs (without trailing dot)
+	Prefix string
+	// GUID of app-host service instance
+	AppHostGUID string
+}
+
+// getDestinations returns destinations of subaccount, if destination service
+// instance name is empty, destinations of service instance with given name,
+// or destinations of all destination service instances of space, if name is "*".
+// Returned destination context should be cleaned by caller
+func (c *HTML5Command) getDestinations(context Context, destinationInstance string) (models.DestinationListDestinationsResponse, DestinationContext, error) {
+	var destinations models.DestinationListDestinationsResponse
+
+	// Get Destination context
+	var destinationContext DestinationContext
+	var err error
+	if destinationInstance != "*" {
+		destinationContext, err = c.GetDestinationContext(context, destinationInstance)
+	} else {
+		destinationContext, err = c.GetDestinationContext(context, "")
+	}
+	if err != nil {
+		return destinations, destinationContext, err
+	}
+
+	if destinationInstance == "" {
+		// List subaccount destinations
+		destinations, err = clients.ListSubaccountDestinations(
+			*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
+			destinationContext.DestinationServiceInstanceKeyToken)
+		if err != nil {
+			return destinations, destinationContext, fmt.Errorf("Could not get list of subaccount destinations: %s", err.Error())
+		}
+		log.Tracef("List of subaccount destinations: %+v\n", destinations)
+		return destinations, destinationContext, nil
+	}
+
+	if destinationInstance != "*" {
+		destinations, err = clients.ListServiceInstanceDestinations(
+			*destinationContext.DestinationServiceInstanceKey.Credentials.URI,
+			destinationContext.DestinationServiceInstanceKeyToken)
+		if err != nil {
+			return destinations, destinationContext, fmt.Errorf("Could not get list of service instance destinations: %s", err.Error())
+		}
+		// Add destination service instance name to each destination
+		for idx := range destinations {
+			(&destinations[idx]).DestinationServiceInstanceName = destinationContext.DestinationServiceInstances[0].Name
+		}
+	} else {
+		for _, destinationServiceInstance := range destinationContext.DestinationServiceInstances {
+			// Get Destination context
+			destinationInstanceContext, err := c.GetDestinationContext(context, destinationServiceInstance.Name)
+			if err != nil {
+				return destinations, destinationContext, err
+			}
+			// List service instance destinations
+			destinationsList, err := clients.ListServiceInstanceDestinations(
+				*destinationInstanceContext.DestinationServiceInstanceKey.Credentials.URI,
+				destinationInstanceContext.DestinationServiceInstanceKeyToken)
+			if err != nil {
+				return destinations, destinationContext, fmt.Errorf("Could not get list of service instance destinations: %s", err.Error())
+			}
+			// Add destination service instance name to each destination
+			for _, destination := range destinationsList {
+				destination.DestinationServiceInstanceName = destinationServiceInstance.Name
+				log.Tracef("Setting destination service instance name to '%s' for destination %+v\n", destinationServiceInstance.Name, destination)
+				destinations = append(destinations, destination)
+			}
+			// Clean-up destination context
+			err = c.CleanDestinationContext(destinationInstanceContext)
+			if err != nil {
+				return destinations, destinationContext, err
+			}
+		}
+	}
+	log.Tracef("List of service instance destinations: %+v\n", destinations)
+
+	return destinations, destinationContext, nil
+}
+
+// getDestinationAppHostGUIDs returns business service name and app-host GUIDs
+// defined in sap.cloud.service and html5-apps-repo.app_host_id (or app_host_id,
+// or html5-apps-repo JSON) properties of destination. Returns false if
+// destination does not expose HTML5 applications
+func getDestinationAppHostGUIDs(destination models.DestinationConfiguration) (string, []string, bool) {
+	serviceName, ok := destination.Properties["sap.cloud.service"]
+	if !ok {
+		return "", nil, false
+	}
+	log.Tracef("Destination '%s' has 'sap.cloud.service' property: %s\n", destination.Name, serviceName)
+	var appHostGUIDs string
+	appHostGUIDs, ok = destination.Properties["html5-apps-repo.app_host_id"]
+	if !ok {
+		appHostGUIDs, ok = destination.Properties["app_host_id"]
+	}
+	if !ok {
+		var html5AppsRepo string
+		if html5AppsRepo, ok = destination.Properties["html5-apps-repo"]; ok &&
+			len(html5AppsRepo) > 0 && html5AppsRepo[0:1] == "{" {
+
+			var html5RepoMap map[string]interface{}
+			var appHostGUIDsInterface interface{}
+			err := json.Unmarshal([]byte(html5AppsRepo), &html5RepoMap)
+			if err != nil {
+				log.Tracef("Could not parse 'hmtl5-apps-repo' property of destination '%s'", destination)
+				ok = false
+			} else if appHostGUIDsInterface, ok = html5RepoMap["app_host_id"]; ok {
+				switch v := appHostGUIDsInterface.(type) {
+				case string:
+					appHostGUIDs = v
+				}
+			}
+		}
+	}
+	if !ok {
+		return serviceName, nil, false
+	}
+	guids := make([]string, 0)
+	for _, appHostGUID := range strings.Split(appHostGUIDs, ",") {
+		guids = append(guids, strings.Trim(appHostGUID, " "))
+	}
+	return serviceName, guids, true
+}
+
+// getDestinationAppHosts returns app-hosts of business services
+// exposed via destinations
+func getDestinationAppHosts(destinations models.DestinationListDestinationsResponse) []ServiceAppHost {
+	appHosts := make([]ServiceAppHost, 0)
+	for _, destination := range destinations {
+		serviceName, appHostGUIDs, ok := getDestinationAppHostGUIDs(destination)
+		if !ok {
+			continue
+		}
+		for _, appHostGUID := range appHostGUIDs {
+			appHosts = append(appHosts, ServiceAppHost{
+				ServiceName: serviceName,
+				Prefix:      strings.Replace(serviceName, ".", "", -1),
+				AppHostGUID: appHostGUID,
+			})
+		}
+	}
+	return appHosts
+}
+
+// getBoundAppHosts returns app-hosts of business services bound
+// to CF application
+func getBoundAppHosts(env *models.CFEnvironmentResponse) []ServiceAppHost {
+	appHosts := make([]ServiceAppHost, 0)
+	for serviceName, serviceBindings := range env.SystemEnvJSON.VCAPServices {
+		for _, serviceBinding := range serviceBindings {
+			if serviceBinding.Credentials.HTML5AppsRepo == nil {
+				continue
+			}
+			prefix := ""
+			if serviceBinding.Credentials.SAPCloudServiceAlias != nil {
+				prefix = *serviceBinding.Credentials.SAPCloudServiceAlias
+			} else if serviceBinding.Credentials.SAPCloudService != nil {
+				prefix = strings.Replace(strings.Replace(*serviceBinding.Credentials.SAPCloudService, ".", "", -1), "-", "", -1)
+			}
+			for _, appHostGUID := range strings.Split(serviceBinding.Credentials.HTML5AppsRepo.AppHostID, ",") {
+				appHosts = append(appHosts, ServiceAppHost{ServiceName: serviceName, Prefix: prefix, AppHostGUID: appHostGUID})
+			}
+		}
+	}
+	return appHosts
+}
+
+// findServiceApp looks for application with key in SERVICE.APP-VERSION
+// (or SERVICE.APP for default version) format in app-hosts of business services
+func findServiceApp(html5Context HTML5Context, appHosts []ServiceAppHost, serviceAppKey string) (ServiceAppHost, models.HTML5App, error) {
+	parts := strings.SplitN(serviceAppKey, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ServiceAppHost{}, models.HTML5App{}, fmt.Errorf("Application '%s' should be specified as SERVICE.APP-VERSION", serviceAppKey)
+	}
+	prefix, appKey := parts[0], parts[1]
+
+	var serviceFound = false
+	services := make([]string, 0)
+	for _, appHost := range appHosts {
+		if indexOfString(services, appHost.Prefix) < 0 {
+			services = append(services, appHost.Prefix)
+		}
+		if appHost.Prefix != prefix {
+			continue
+		}
+		serviceFound = true
+		log.Tracef("Looking for application '%s' in app-host-id '%s' of service '%s'\n", appKey, appHost.AppHostGUID, appHost.ServiceName)
+		applications, err := clients.ListApplicationsForAppHost(
+			*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+			html5Context.HTML5AppRuntimeServiceInstanceKeyToken, appHost.AppHostGUID)
+		if err != nil {
+			// Invalid app-host-id
+			if strings.Index(err.Error(), "HTTP 400") >= 0 {
+				log.Tracef("Skipping invalid app-host-id '%s' of service '%s'\n", appHost.AppHostGUID, appHost.ServiceName)
+				continue
+			}
+			return appHost, models.HTML5App{}, fmt.Errorf("Could not get list of applications for app-host-id '%s' of service '%s': %+v", appHost.AppHostGUID, appHost.ServiceName, err)
+		}
+		for _, application := range applications {
+			if application.ApplicationName+"-"+application.ApplicationVersion == appKey ||
+				(application.ApplicationName == appKey && application.IsDefault) {
+				log.Tracef("Found application '%s' in app-host-id '%s'\n", appKey, appHost.AppHostGUID)
+				return appHost, application, nil
+			}
+		}
+	}
+
+	if !serviceFound {
+		if len(services) == 0 {
+			return ServiceAppHost{}, models.HTML5App{}, fmt.Errorf("No business services with HTML5 applications are available")
+		}
+		return ServiceAppHost{}, models.HTML5App{}, fmt.Errorf("Business service '%s' is not available. Available services: %s", prefix, strings.Join(services, ", "))
+	}
+	return ServiceAppHost{}, models.HTML5App{}, fmt.Errorf("Application '%s' is not found in app-hosts of business service '%s'", appKey, prefix)
+}
@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of snapshot metadata file in snapshot directory
+	snapshotFileName = "snapshot.json"
+	// Number of snapshots kept for each app-host by default
+	defaultSnapshotsKeep = 5
+	// Format of snapshot identifiers
+	snapshotIDFormat = "20060102T150405Z"
+)
+
+var snapshotsDirPath = homeDir() + slash +
+	".cf" + slash +
+	"plugins" + slash +
+	"html5-snapshots"
+
+// Snapshot content of app-host saved locally before it was overwritten
+type Snapshot struct {
+	// Identifier of snapshot (UTC timestamp)
+	ID string `json:"id"`
+	// GUID of app-host service instance
+	AppHostGUID string `json:"appHostGUID"`
+	// Time of snapshot creation
+	CreatedOn time.Time `json:"createdOn"`
+	// Applications of app-host
+	Apps []SnapshotApp `json:"apps"`
+	// Directory of snapshot
+	Dir string `json:"-"`
+}
+
+// SnapshotApp application archive in snapshot
+type SnapshotApp struct {
+	// Name of application
+	Name string `json:"name"`
+	// Version of application
+	Version string `json:"version"`
+	// Application version is default
+	IsDefault bool `json:"isDefault,omitempty"`
+	// Name of archive file in snapshot directory
+	File string `json:"file"`
+	// Size of archive file in bytes
+	Size int64 `json:"size"`
+	// Hex-encoded SHA-256 hash of archive file
+	SHA256 string `json:"sha256"`
+}
+
+// Size returns total size of application archives in snapshot
+func (s Snapshot) Size() int64 {
+	var size int64
+	for _, app := range s.Apps {
+		size += app.Size
+	}
+	return size
+}
+
+// ZipFiles returns paths of application archives in snapshot
+func (s Snapshot) ZipFiles() []string {
+	zipFiles := make([]string, 0)
+	for _, app := range s.Apps {
+		zipFiles = append(zipFiles, filepath.Join(s.Dir, app.File))
+	}
+	return zipFiles
+}
+
+// getSnapshotsDir returns directory with snapshots of app-host
+func getSnapshotsDir(appHostGUID string) string {
+	return filepath.Join(snapshotsDirPath, appHostGUID)
+}
+
+// getSnapshotsKeep returns number of snapshots to keep for each app-host.
+// Can be changed with HTML5_SNAPSHOTS_KEEP environment variable
+func getSnapshotsKeep() int {
+	keep, err := strconv.Atoi(os.Getenv("HTML5_SNAPSHOTS_KEEP"))
+	if err != nil || keep < 1 {
+		return defaultSnapshotsKeep
+	}
+	return keep
+}
+
+// createSnapshot downloads all applications of app-host and stores them
+// as zip archives in local snapshot store. Returns nil, if app-host has
+// no applications
+func createSnapshot(html5Context HTML5Context, appHostGUID string) (*Snapshot, error) {
+	serviceURL := *html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI
+	accessToken := html5Context.HTML5AppRuntimeServiceInstanceKeyToken
+
+	// Get list of applications for app-host-id
+	log.Tracef("Getting list of applications for app-host-id %s\n", appHostGUID)
+	applications, err := clients.ListApplicationsForAppHost(serviceURL, accessToken, appHostGUID)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get list of applications for app-host-id %s: %+v", appHostGUID, err)
+	}
+	if len(applications) == 0 {
+		log.Tracef("App-host-id %s has no applications, snapshot is not created\n", appHostGUID)
+		return nil, nil
+	}
+
+	// Snapshot identifier
+	dir := getSnapshotsDir(appHostGUID)
+	now := time.Now().UTC()
+	snapshot := Snapshot{ID: now.Format(snapshotIDFormat), AppHostGUID: appHostGUID, CreatedOn: now, Apps: make([]SnapshotApp, 0)}
+	for suffix := 1; ; suffix++ {
+		if _, err := os.Stat(filepath.Join(dir, snapshot.ID)); os.IsNotExist(err) {
+			break
+		}
+		snapshot.ID = now.Format(snapshotIDFormat) + "-" + strconv.Itoa(suffix)
+	}
+	snapshot.Dir = filepath.Join(dir, snapshot.ID)
+
+	// Snapshot is prepared in temporary directory, which is renamed
+	// when complete, so interrupted snapshots are never listed
+	tmp := filepath.Join(dir, "."+snapshot.ID+".tmp")
+	log.Tracef("Creating snapshot of app-host-id %s in %s\n", appHostGUID, tmp)
+	err = os.MkdirAll(tmp, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create snapshot directory %s: %+v", tmp, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// Download and zip applications
+	filesDir := filepath.Join(tmp, "files")
+	for _, application := range applications {
+		appKey := application.ApplicationName + "-" + application.ApplicationVersion
+		log.Tracef("Getting list of files for application '%s'\n", appKey)
+		files, err := clients.ListFilesOfApp(serviceURL, appKey, accessToken, appHostGUID)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get list of files for app %s: %+v", appKey, err)
+		}
+		_, err = downloadAppFiles(html5Context, appHostGUID, files, filesDir, false)
+		if err != nil {
+			return nil, err
+		}
+		zipPath := filepath.Join(tmp, appKey+".zip")
+		_, err = zipAppDirectory(filepath.Join(filesDir, appKey), zipPath, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		size, hash, err := getFileHash(zipPath)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read archive %s: %+v", zipPath, err)
+		}
+		snapshot.Apps = append(snapshot.Apps, SnapshotApp{
+			Name:      application.ApplicationName,
+			Version:   application.ApplicationVersion,
+			IsDefault: application.IsDefault,
+			File:      appKey + ".zip",
+			Size:      size,
+			SHA256:    hash,
+		})
+	}
+	err = os.RemoveAll(filesDir)
+	if err != nil {
+		return nil, fmt.Errorf("Could not delete directory %s: %+v", filesDir, err)
+	}
+
+	// Write snapshot metadata
+	snapshotJSON, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal snapshot: %+v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tmp, snapshotFileName), snapshotJSON, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Could not write snapshot file: %+v", err)
+	}
+
+	// Complete snapshot
+	err = os.Rename(tmp, snapshot.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create snapshot directory %s: %+v", snapshot.Dir, err)
+	}
+	log.Tracef("Snapshot %s of app-host-id %s created: %+v\n", snapshot.ID, appHostGUID, snapshot)
+
+	return &snapshot, nil
+}
+
+// loadSnapshots returns snapshots of app-host sorted from newest to oldest
+func loadSnapshots(appHostGUID string) ([]Snapshot, error) {
+	snapshots := make([]Snapshot, 0)
+
+	dir := getSnapshotsDir(appHostGUID)
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return snapshots, fmt.Errorf("Could not read snapshots directory %s: %+v", dir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		snapshotDir := filepath.Join(dir, entry.Name())
+		snapshotJSON, err := ioutil.ReadFile(filepath.Join(snapshotDir, snapshotFileName))
+		if err != nil {
+			log.Tracef("Skipping directory %s without snapshot file: %+v\n", snapshotDir, err)
+			continue
+		}
+		var snapshot Snapshot
+		err = json.Unmarshal(snapshotJSON, &snapshot)
+		if err != nil {
+			return snapshots, fmt.Errorf("Could not parse snapshot file in %s: %+v", snapshotDir, err)
+		}
+		snapshot.Dir = snapshotDir
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedOn.After(snapshots[j].CreatedOn)
+	})
+
+	return snapshots, nil
+}
+
+// verifySnapshot checks that application archives of snapshot exist
+// and were not modified since snapshot creation
+func verifySnapshot(snapshot Snapshot) error {
+	if len(snapshot.Apps) == 0 {
+		return fmt.Errorf("Snapshot %s has no applications", snapshot.ID)
+	}
+	for _, app := range snapshot.Apps {
+		zipPath := filepath.Join(snapshot.Dir, app.File)
+		size, hash, err := getFileHash(zipPath)
+		if err != nil {
+			return fmt.Errorf("Could not read archive %s of snapshot %s: %+v", app.File, snapshot.ID, err)
+		}
+		if size != app.Size || hash != app.SHA256 {
+			return fmt.Errorf("Archive %s of snapshot %s is corrupted: expected size %d and SHA-256 %s, got size %d and SHA-256 %s",
+				app.File, snapshot.ID, app.Size, app.SHA256, size, hash)
+		}
+	}
+	return nil
+}
+
+// pruneSnapshots deletes the oldest snapshots of app-host,
+// keeping given number of newest ones
+func pruneSnapshots(appHostGUID string, keep int) error {
+	snapshots, err := loadSnapshots(appHostGUID)
+	if err != nil {
+		return err
+	}
+	for idx, snapshot := range snapshots {
+		if idx < keep {
+			continue
+		}
+		log.Tracef("Deleting snapshot %s of app-host-id %s\n", snapshot.ID, appHostGUID)
+		err = os.RemoveAll(snapshot.Dir)
+		if err != nil {
+			return fmt.Errorf("Could not delete snapshot directory %s: %+v", snapshot.Dir, err)
+		}
+	}
+	return nil
+}
+
+// getFileHash returns size and hex-encoded SHA-256 hash of file
+func getFileHash(filePath string) (int64, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,476 @@
+package commands
+
+import (
+	clients "cf-html5-apps-repo-cli-plugin/clients"
+	"cf-html5-apps-repo-cli-plugin/clients/models"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// Name of state file in synchronized directory
+const syncStateFileName = ".html5-sync.json"
+
+// Actions applied to files by html5-sync
+const (
+	syncActionAdded     = "added"
+	syncActionUpdated   = "updated"
+	syncActionRemoved   = "removed"
+	syncActionUnchanged = "unchanged"
+)
+
+// SyncCommand mirror content of app-host service instance
+// into local directory
+type SyncCommand struct {
+	HTML5Command
+}
+
+// SyncState state of synchronized directory
+type SyncState struct {
+	// GUID of synchronized app-host service instance
+	AppHostGUID string `json:"appHostGUID"`
+	// Time of last synchronization
+	SyncedOn time.Time `json:"syncedOn"`
+	// Synchronized files by path, starting from /<appName-appVersion>
+	Files map[string]SyncStateFile `json:"files"`
+}
+
+// SyncStateFile state of synchronized file
+type SyncStateFile struct {
+	// ETag of file in app-host
+	ETag string `json:"etag"`
+	// Size of file in bytes
+	Size int `json:"size"`
+}
+
+// SyncFile file of app-host with synchronization decision
+type SyncFile struct {
+	Path   string
+	Action string
+	// ETag for conditional request, if file is possibly changed
+	ETag     string
+	Metadata models.HTML5ApplicationFileMetadata
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *SyncCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "html5-sync",
+		HelpText: "Mirror content of app-host service instance into local directory, downloading only new or changed files",
+		UsageDetails: plugin.Usage{
+			Usage: "cf html5-sync APP_HOST_ID|-n APP_HOST_NAME [DIR]",
+			Options: map[string]string{
+				"-name,-n":      "Use app-host service instance with specified name",
+				"APP_HOST_ID":   "GUID of html5-apps-repo app-host service instance",
+				"APP_HOST_NAME": "Name of html5-apps-repo app-host service instance",
+				"DIR":           "Local directory with content of app-host. Default is current working directory",
+			},
+		},
+	}
+}
+
+// Execute executes plugin command
+func (c *SyncCommand) Execute(args []string) ExecutionStatus {
+	log.Tracef("Executing command '%s': args: '%v'\n", c.Name, args)
+
+	flagSet := flag.NewFlagSet("html5-sync", flag.ContinueOnError)
+	nameFlag := flagSet.String("name", "", "app-host service instance name")
+	nameFlagAlias := flagSet.String("n", "", "app-host service instance name")
+	err := flagSet.Parse(args)
+	if err != nil {
+		ui.Failed("Could not parse arguments: %+v", err)
+		return Failure
+	}
+
+	// Normalize aliases
+	appHostName := *nameFlagAlias
+	if *nameFlag != "" {
+		appHostName = *nameFlag
+	}
+
+	if appHostName != "" && flagSet.NArg() <= 1 {
+		return c.SyncAppHost(appHostName, true, flagSet.Arg(0))
+	}
+	if appHostName == "" && (flagSet.NArg() == 1 || flagSet.NArg() == 2) {
+		return c.SyncAppHost(flagSet.Arg(0), false, flagSet.Arg(1))
+	}
+
+	ui.Failed("Incorrect number of arguments passed. See [cf html5-sync --help] for more details")
+	return Failure
+}
+
+// SyncAppHost download new and changed files of app-host into directory
+// and delete files, which no longer exist in app-host
+func (c *SyncCommand) SyncAppHost(appHostNameOrGUID string, isName bool, dir string) ExecutionStatus {
+	log.Tracef("Synchronizing content of app-host '%s' with directory '%s'\n", appHostNameOrGUID, dir)
+
+	// Get context
+	log.Tracef("Getting context (org/space/username)\n")
+	context, err := c.GetContext()
+	if err != nil {
+		ui.Failed("Could not get org and space: %s", err.Error())
+		return Failure
+	}
+
+	// Local directory
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			ui.Failed("Could not get current working directory")
+			return Failure
+		}
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		ui.Failed("Could not resolve directory %s: %+v", dir, err)
+		return Failure
+	}
+
+	ui.Say("Synchronizing content of app-host %s with directory %s in org %s / space %s as %s...",
+		terminal.EntityNameColor(appHostNameOrGUID),
+		terminal.EntityNameColor(dir),
+		terminal.EntityNameColor(context.Org),
+		terminal.EntityNameColor(context.Space),
+		terminal.EntityNameColor(context.Username))
+
+	// Get HTML5 context
+	html5Context, err := c.GetHTML5Context(context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	appHostGUID := appHostNameOrGUID
+	if isName {
+		// Resolve app-host-id
+		log.Tracef("Resolving app-host-id by service instance name '%s'\n", appHostNameOrGUID)
+		serviceInstance, err := clients.GetServiceInstanceByName(c.CliConnection, context.SpaceID, appHostNameOrGUID)
+		if err != nil {
+			ui.Failed("%+v", err)
+			return Failure
+		}
+		log.Tracef("Resolved app-host-id is '%s'\n", serviceInstance.GUID)
+		appHostGUID = serviceInstance.GUID
+	}
+
+	// Read state of directory
+	state, err := loadSyncState(dir)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+	if state.AppHostGUID != "" && state.AppHostGUID != appHostGUID {
+		ui.Failed("Directory %s is a mirror of app-host-id %s and can not be synchronized with app-host-id %s",
+			dir, state.AppHostGUID, appHostGUID)
+		return Failure
+	}
+	state.AppHostGUID = appHostGUID
+
+	// Get list of applications for app-host-id
+	log.Tracef("Getting list of applications for app-host-id %s\n", appHostGUID)
+	applications, err := clients.ListApplicationsForAppHost(*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+		html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+		appHostGUID)
+	if err != nil {
+		ui.Failed("Could not get list of applications for app-host-id %s: %+v", appHostGUID, err)
+		return Failure
+	}
+
+	// Get size and ETag of deployed files
+	files := make([]SyncFile, 0)
+	for _, application := range applications {
+		appKey := application.ApplicationName + "-" + application.ApplicationVersion
+		deployedFiles, err := getDeployedAppFiles(html5Context, appHostGUID, appKey)
+		if err != nil {
+			ui.Failed(err.Error())
+			return Failure
+		}
+		for _, deployedFile := range deployedFiles {
+			if !isValidSyncPath(deployedFile.FilePath) {
+				ui.Failed("Could not synchronize file with unexpected path %s", deployedFile.FilePath)
+				return Failure
+			}
+			files = append(files, SyncFile{Path: deployedFile.FilePath, Metadata: deployedFile.FileMetadata})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	// Compare deployed files with state of directory
+	for idx, file := range files {
+		files[idx].Action, files[idx].ETag = getSyncAction(dir, state, file)
+		log.Tracef("File %s: %s\n", file.Path, files[idx].Action)
+	}
+
+	// Download new and changed files
+	err = c.syncFiles(html5Context, appHostGUID, dir, files, &state)
+	if err != nil {
+		saveErr := saveSyncState(dir, state)
+		if saveErr != nil {
+			log.Tracef("Could not save state of directory %s: %+v\n", dir, saveErr)
+		}
+		printDownloadError(err)
+		return Failure
+	}
+
+	// Delete files, which no longer exist in app-host
+	deployed := make(map[string]bool)
+	for _, file := range files {
+		deployed[file.Path] = true
+	}
+	removedPaths := make([]string, 0)
+	for filePath := range state.Files {
+		if !deployed[filePath] {
+			removedPaths = append(removedPaths, filePath)
+		}
+	}
+	sort.Strings(removedPaths)
+	for _, filePath := range removedPaths {
+		err = removeSyncedFile(dir, filePath)
+		if err != nil {
+			saveErr := saveSyncState(dir, state)
+			if saveErr != nil {
+				log.Tracef("Could not save state of directory %s: %+v\n", dir, saveErr)
+			}
+			ui.Failed(err.Error())
+			return Failure
+		}
+		delete(state.Files, filePath)
+		files = append(files, SyncFile{Path: filePath, Action: syncActionRemoved})
+	}
+
+	// Save state of directory
+	state.SyncedOn = time.Now().UTC()
+	err = saveSyncState(dir, state)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	// Clean-up HTML5 context
+	err = c.CleanHTML5Context(html5Context)
+	if err != nil {
+		ui.Failed(err.Error())
+		return Failure
+	}
+
+	ui.Ok()
+	ui.Say("")
+
+	// Display changed files and summary
+	summary := make(map[string]int)
+	table := ui.Table([]string{"path", "action"})
+	for _, file := range files {
+		summary[file.Action]++
+		if file.Action != syncActionUnchanged {
+			table.Add(file.Path, file.Action)
+		}
+	}
+	if len(files) > summary[syncActionUnchanged] {
+		table.Print()
+		ui.Say("")
+	}
+	ui.Say("Added: %d, updated: %d, removed: %d, unchanged: %d",
+		summary[syncActionAdded], summary[syncActionUpdated], summary[syncActionRemoved], summary[syncActionUnchanged])
+
+	return Success
+}
+
+// syncFiles downloads new and changed files concurrently. Changed files
+// are requested conditionally, files not modified since last synchronization
+// are marked as unchanged. State of directory is updated for each saved file.
+// Files, which could not be downloaded, are returned as DownloadError
+func (c *SyncCommand) syncFiles(html5Context HTML5Context, appHostGUID string, dir string, files []SyncFile, state *SyncState) error {
+	// Rate limiter for cuncurrent connections
+	rateLimiter := make(chan int, maxConcurrentConnections)
+
+	// Get and save files
+	filesChannels := make(map[int]chan syncResult)
+	for idx, file := range files {
+		if file.Action != syncActionAdded && file.Action != syncActionUpdated {
+			continue
+		}
+		filesChannel := make(chan syncResult, 1)
+		filesChannels[idx] = filesChannel
+		go func(file SyncFile, idx int) {
+			rateLimiter <- idx
+			filesChannel <- syncFile(html5Context, appHostGUID, dir, file)
+		}(file, idx)
+	}
+
+	// Update state of directory. All results are collected, so failed
+	// files are reported together and state of saved files is kept
+	failures := make([]DownloadFailure, 0)
+	for i := 0; i < len(filesChannels); i++ {
+		var idx int = <-rateLimiter
+		file := files[idx]
+		result := <-filesChannels[idx]
+		if result.Error != nil {
+			failures = append(failures, DownloadFailure{Path: file.Path, Error: result.Error})
+			continue
+		}
+		if result.NotModified {
+			log.Tracef("File %s is not modified since last synchronization\n", file.Path)
+			files[idx].Action = syncActionUnchanged
+			state.Files[file.Path] = SyncStateFile{ETag: file.Metadata.ETag, Size: state.Files[file.Path].Size}
+			continue
+		}
+		state.Files[file.Path] = SyncStateFile{ETag: result.ETag, Size: result.Size}
+	}
+	if len(failures) > 0 {
+		sort.SliceStable(failures, func(i, j int) bool { return failures[i].Path < failures[j].Path })
+		return &DownloadError{Failures: failures, Total: len(filesChannels)}
+	}
+
+	return nil
+}
+
+// syncResult result of synchronization of single file
+type syncResult struct {
+	// ETag of saved file
+	ETag string
+	// Size of saved file
+	Size int
+	// File is not modified since last synchronization
+	NotModified bool
+	Error       error
+}
+
+// syncFile downloads file, if it is modified since last synchronization,
+// and streams its content into directory
+func syncFile(html5Context HTML5Context, appHostGUID string, dir string, file SyncFile) syncResult {
+	fileContent, err := clients.GetFileContentIfNoneMatch(
+		*html5Context.HTML5AppRuntimeServiceInstanceKeys[len(html5Context.HTML5AppRuntimeServiceInstanceKeys)-1].Credentials.URI,
+		file.Path,
+		html5Context.HTML5AppRuntimeServiceInstanceKeyToken,
+		appHostGUID,
+		file.ETag)
+	if err != nil {
+		return syncResult{Error: err}
+	}
+	if fileContent.NotModified {
+		return syncResult{NotModified: true}
+	}
+	defer fileContent.Content.Close()
+	err = saveAppFile(dir, file.Path, fileContent.Content)
+	if err != nil {
+		return syncResult{Error: err}
+	}
+	info, err := os.Stat(dir + strings.Replace(file.Path, "/", slash, -1))
+	if err != nil {
+		return syncResult{Error: fmt.Errorf("Could not read file %s: %+v", file.Path, err)}
+	}
+	etag := fileContent.ETag
+	if etag == "" {
+		etag = file.Metadata.ETag
+	}
+	return syncResult{ETag: etag, Size: int(info.Size())}
+}
+
+// getSyncAction decides, if deployed file should be downloaded. Returns
+// action and ETag for conditional request, if file is possibly changed
+func getSyncAction(dir string, state SyncState, file SyncFile) (string, string) {
+	stateFile, ok := state.Files[file.Path]
+	if !ok {
+		return syncActionAdded, ""
+	}
+
+	// Local file was deleted or modified, download it unconditionally
+	info, err := os.Stat(dir + strings.Replace(file.Path, "/", slash, -1))
+	if err != nil || info.IsDir() || int(info.Size()) != stateFile.Size {
+		log.Tracef("Local file %s does not match state of directory\n", file.Path)
+		return syncActionUpdated, ""
+	}
+
+	if file.Metadata.ETag != stateFile.ETag || file.Metadata.FileSize != stateFile.Size {
+		return syncActionUpdated, stateFile.ETag
+	}
+	return syncActionUnchanged, ""
+}
+
+// isValidSyncPath checks that file path is absolute, clean and can not
+// point outside of synchronized directory
+func isValidSyncPath(filePath string) bool {
+	return filePath != "/" &&
+		!strings.Contains(filePath, "\\") &&
+		path.Clean("/"+strings.TrimPrefix(filePath, "/")) == filePath
+}
+
+// removeSyncedFile deletes local file and its empty parent directories
+func removeSyncedFile(dir string, filePath string) error {
+	localPath := dir + strings.Replace(filePath, "/", slash, -1)
+	log.Tracef("Deleting file %s\n", localPath)
+	err := os.Remove(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Could not delete file %s: %+v", localPath, err)
+	}
+	for parent := filepath.Dir(localPath); strings.HasPrefix(parent, dir+slash); parent = filepath.Dir(parent) {
+		entries, err := ioutil.ReadDir(parent)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		log.Tracef("Deleting empty directory %s\n", parent)
+		os.Remove(parent)
+	}
+	return nil
+}
+
+// loadSyncState reads state file of synchronized directory.
+// Returns empty state, if directory was not synchronized before
+func loadSyncState(dir string) (SyncState, error) {
+	state := SyncState{Files: make(map[string]SyncStateFile)}
+
+	fileName := filepath.Join(dir, syncStateFileName)
+	fileContents, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		log.Tracef("State file '%s' does not exist\n", fileName)
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("Could not read state file '%s': %s", fileName, err.Error())
+	}
+	err = json.Unmarshal(fileContents, &state)
+	if err != nil {
+		return state, fmt.Errorf("Could not parse state file '%s': %s", fileName, err.Error())
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]SyncStateFile)
+	}
+	for filePath := range state.Files {
+		if !isValidSyncPath(filePath) {
+			return state, fmt.Errorf("Could not use state file '%s' with unexpected path %s", fileName, filePath)
+		}
+	}
+
+	return state, nil
+}
+
+// saveSyncState writes state file of synchronized directory
+func saveSyncState(dir string, state SyncState) error {
+	fileName := filepath.Join(dir, syncStateFileName)
+	stateJSON, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Could not marshal state of directory: %+v", err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("Could not create directory %s: %+v", dir, err)
+	}
+	log.Tracef("Writing state file %s\n", fileName)
+	err = ioutil.WriteFile(fileName, stateJSON, 0644)
+	if err != nil {
+		return fmt.Errorf("Could not write state file '%s': %s", fileName, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsValidSyncPath(t *testing.T) {
+	tests := map[string]bool{
+		"/app-1.0.0/index.html":         true,
+		"/app-1.0.0/webapp/i18n.json":   true,
+		"/app-1.0.0/..index.html":       true,
+		"":                              false,
+		"/":                             false,
+		"app-1.0.0/index.html":          false,
+		"/../index.html":                false,
+		"/app-1.0.0/../../index.html":   false,
+		"/app-1.0.0/./index.html":       false,
+		"/app-1.0.0//index.html":        false,
+		"/app-1.0.0/":                   false,
+		"/app-1.0.0/..\\..\\index.html": false,
+	}
+	for filePath, expected := range tests {
+		if valid := isValidSyncPath(filePath); valid != expected {
+			t.Errorf("isValidSyncPath(%q) = %v, expected %v", filePath, valid, expected)
+		}
+	}
+}
+
+func TestLoadSyncState(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		files   int
+		fails   bool
+	}{
+		{
+			name:  "missing",
+			files: 0,
+		},
+		{
+			name:    "valid",
+			content: `{"appHostGUID": "app-host-1", "files": {"/app-1.0.0/index.html": {"etag": "\"abc\"", "size": 10}}}`,
+			files:   1,
+		},
+		{
+			name:    "without files",
+			content: `{"appHostGUID": "app-host-1"}`,
+			files:   0,
+		},
+		{
+			name:    "path outside of directory",
+			content: `{"appHostGUID": "app-host-1", "files": {"/app-1.0.0/index.html": {}, "/../../.bashrc": {}}}`,
+			fails:   true,
+		},
+		{
+			name:    "relative path",
+			content: `{"appHostGUID": "app-host-1", "files": {"app-1.0.0/index.html": {}}}`,
+			fails:   true,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"files": `,
+			fails:   true,
+		},
+	}
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "html5-sync-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if test.content != "" {
+			if err = ioutil.WriteFile(filepath.Join(dir, syncStateFileName), []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		state, err := loadSyncState(dir)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: loadSyncState returned error %v, expected failure = %v", test.name, err, test.fails)
+			continue
+		}
+		if !test.fails && len(state.Files) != test.files {
+			t.Errorf("%s: loadSyncState returned %d files, expected %d", test.name, len(state.Files), test.files)
+		}
+	}
+}
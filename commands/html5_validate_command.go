@@ -0,0 +1,446 @@
+package commands
+
+import (
+	"archive/zip"
+	"cf-html5-apps-repo-cli-plugin/log"
+	"cf-html5-apps-repo-cli-plugin/ui"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/plugin"
+)
+
+// Allowed values of xs-app.json "authenticationMethod"
+var validAuthenticationMethods = []string{"none", "route"}
+
+// Allowed values of xs-app.json route "authenticationType"
+var validAuthenticationTypes = []string{"xsuaa", "ias", "basic", "none"}
+
+// Allowed keys of xs-app.json route "scope" object
+var validScopeVerbs = []string{"default", "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE"}
+
+var appIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-]*$`)
+var serviceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)*$`)
+var scopeRegexp = regexp.MustCompile(`^(\$XSAPPNAME(\([^()]+\))?\.)?[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)*$`)
+
+// ValidateCommand validates application descriptors of HTML5 applications
+type ValidateCommand struct {
+	BaseCommand
+}
+
+// ValidationProblem problem found in application descriptor
+type ValidationProblem struct {
+	// Path to the file with problem
+	File string
+	// Path to the problematic JSON property
+	Path string
+	// Problem description
+	Message string
+	// Problem does not prevent application from being pushed
+	Warning bool
+}
+
+// GetPluginCommand returns the plugin command details
+func (c *ValidateCommand) GetPluginCommand() plugin.Command {
+	return plugin.Command{
+		Name:     "html5-validate",
+		HelpText: "Validate manifest.json and xs-app.json of HTML5 applications",
+		UsageDetails: plugin.Usage{
+			Usage: "cf html5-validate [PATH_TO_APP_FOLDER ...]",
+			Options: map[string]string{
+				"PATH_TO_APP_FOLDER": "One or multiple paths (or glob patterns) to folders or zip archives containing manifest.json and xs-app.json files, or to folders with such zip archives",
+			},
+		},
+	}
+}
+
+// Execute executes plugin command
+func (c *ValidateCommand) Execute(args []string) ExecutionStatus {
+	log.Tracef("Executing command '%s': args: '%v'\n", c.Name, args)
+
+	// Get current working directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		ui.Failed("Could not get current working directory")
+		return Failure
+	}
+
+	// No arguments passed
+	if len(args) == 0 {
+		log.Tracef("No arguments passed. Looking for application directories\n")
+		dirs, err := findAppDirectories(cwd, false, PushOptions{})
+		if err != nil {
+			ui.Failed("%+v", err)
+			return Failure
+		}
+		return c.ValidateHTML5Applications(dirs)
+	}
+
+	// Check paths are application directories or archives
+	dirs := make([]string, 0)
+	for _, dir := range expandAppPaths(args) {
+		if isAppDirectory(dir) || isAppArchive(dir) {
+			dirs = appendUniquePath(dirs, dir)
+		} else if archives := findAppArchives(dir); len(archives) > 0 {
+			for _, archive := range archives {
+				dirs = appendUniquePath(dirs, archive)
+			}
+		} else {
+			ui.Say("%s%s%s",
+				terminal.AdvisoryColor("WARNING: Path '"),
+				terminal.EntityNameColor(dir),
+				terminal.AdvisoryColor("' is not an application and will not be validated!\n"))
+		}
+	}
+	if len(dirs) == 0 {
+		ui.Failed("Nothing to validate. Make sure provided directories or zip archives contain manifest.json and xs-app.json files")
+		return Failure
+	}
+
+	return c.ValidateHTML5Applications(dirs)
+}
+
+// ValidateHTML5Applications validates application descriptors
+// of HTML5 applications in given directories or archives
+func (c *ValidateCommand) ValidateHTML5Applications(dirs []string) ExecutionStatus {
+	ui.Say("Validating HTML5 applications...")
+
+	problems := make([]ValidationProblem, 0)
+	for _, dir := range dirs {
+		problems = append(problems, validateHTML5Application(dir, nil)...)
+	}
+	errors, warnings := splitValidationProblems(problems)
+	if len(errors) > 0 {
+		printValidationProblems(problems)
+		ui.Failed("Found %d problem(s) in %d application(s)", len(errors), len(dirs))
+		return Failure
+	}
+
+	ui.Ok()
+	if len(warnings) > 0 {
+		printValidationProblems(warnings)
+	}
+	ui.Say("")
+	table := ui.Table([]string{"path", "status"})
+	for _, dir := range dirs {
+		table.Add(dir, "valid")
+	}
+	table.Print()
+	ui.Say("")
+
+	return Success
+}
+
+// printValidationProblems prints table of validation problems
+func printValidationProblems(problems []ValidationProblem) {
+	ui.Say("")
+	table := ui.Table([]string{"file", "path", "severity", "problem"})
+	for _, problem := range problems {
+		table.Add(problem.File, problem.Path, (map[bool]string{true: "warning", false: "error"})[problem.Warning], problem.Message)
+	}
+	table.Print()
+	ui.Say("")
+}
+
+// splitValidationProblems splits validation problems into errors and warnings
+func splitValidationProblems(problems []ValidationProblem) ([]ValidationProblem, []ValidationProblem) {
+	errors := make([]ValidationProblem, 0)
+	warnings := make([]ValidationProblem, 0)
+	for _, problem := range problems {
+		if problem.Warning {
+			warnings = append(warnings, problem)
+		} else {
+			errors = append(errors, problem)
+		}
+	}
+	return errors, warnings
+}
+
+// validateHTML5Application validates manifest.json and xs-app.json
+// of HTML5 application in given directory or archive. Files are validated
+// as changed by transformer, which can be nil
+func validateHTML5Application(appPath string, transform *appTransformer) []ValidationProblem {
+	problems := make([]ValidationProblem, 0)
+	problems = append(problems, validateManifest(appPath, transform)...)
+	problems = append(problems, validateAppDescriptor(appPath, transform)...)
+	log.Tracef("Validation problems of '%s': %+v\n", appPath, problems)
+	return problems
+}
+
+// validateManifest validates application manifest (manifest.json)
+func validateManifest(appPath string, transform *appTransformer) []ValidationProblem {
+	var manifest map[string]interface{}
+
+	fileName := appPath + slash + "manifest.json"
+	problems := make([]ValidationProblem, 0)
+	report := func(path string, message string, args ...interface{}) {
+		problems = append(problems, ValidationProblem{File: fileName, Path: path, Message: fmt.Sprintf(message, args...)})
+	}
+	warn := func(path string, message string, args ...interface{}) {
+		problems = append(problems, ValidationProblem{File: fileName, Path: path, Message: fmt.Sprintf(message, args...), Warning: true})
+	}
+
+	fileContents, err := readTransformedAppFile(appPath, "manifest.json", transform)
+	if err != nil {
+		report("", "Could not read file: %s", err.Error())
+		return problems
+	}
+	err = json.Unmarshal(fileContents, &manifest)
+	if err != nil {
+		report("", "Invalid JSON: %s", err.Error())
+		return problems
+	}
+
+	// sap.app namespace
+	sapApp, ok := manifest["sap.app"].(map[string]interface{})
+	if !ok {
+		report("sap.app", "Required object is missing")
+	} else {
+		// Application name
+		id, ok := sapApp["id"].(string)
+		if !ok || id == "" {
+			report("sap.app/id", "Required string is missing")
+		} else if !appIDRegexp.MatchString(id) {
+			report("sap.app/id", "Value '%s' should contain only latin letters, digits, '_', '.' and '-'", id)
+		} else if strings.Replace(strings.Replace(id, ".", "", -1), "-", "", -1) == "" {
+			report("sap.app/id", "Value '%s' should contain at least one letter or digit", id)
+		}
+
+		// Application version
+		applicationVersion, ok := sapApp["applicationVersion"].(map[string]interface{})
+		if !ok {
+			report("sap.app/applicationVersion", "Required object is missing")
+		} else {
+			version, ok := applicationVersion["version"].(string)
+			if !ok || version == "" {
+				report("sap.app/applicationVersion/version", "Required string is missing")
+			} else if _, err := semver.ParseTolerant(version); err != nil {
+				warn("sap.app/applicationVersion/version", "Value '%s' is not a valid semantic version: %s", version, err.Error())
+			}
+		}
+	}
+
+	// sap.cloud namespace
+	if value, exists := manifest["sap.cloud"]; exists {
+		sapCloud, ok := value.(map[string]interface{})
+		if !ok {
+			report("sap.cloud", "Value should be an object")
+		} else {
+			if value, exists := sapCloud["service"]; exists {
+				service, ok := value.(string)
+				if !ok {
+					report("sap.cloud/service", "Value should be a string")
+				} else if !serviceNameRegexp.MatchString(service) {
+					report("sap.cloud/service", "Value '%s' should consist of latin letters, digits, '_' and '-' separated by '.'", service)
+				}
+			}
+			if value, exists := sapCloud["public"]; exists {
+				if _, ok := value.(bool); !ok {
+					report("sap.cloud/public", "Value should be a boolean")
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateAppDescriptor validates application descriptor (xs-app.json)
+func validateAppDescriptor(appPath string, transform *appTransformer) []ValidationProblem {
+	var descriptor map[string]interface{}
+
+	fileName := appPath + slash + "xs-app.json"
+	problems := make([]ValidationProblem, 0)
+	report := func(path string, message string, args ...interface{}) {
+		problems = append(problems, ValidationProblem{File: fileName, Path: path, Message: fmt.Sprintf(message, args...)})
+	}
+	warn := func(path string, message string, args ...interface{}) {
+		problems = append(problems, ValidationProblem{File: fileName, Path: path, Message: fmt.Sprintf(message, args...), Warning: true})
+	}
+
+	fileContents, err := readTransformedAppFile(appPath, "xs-app.json", transform)
+	if err != nil {
+		report("", "Could not read file: %s", err.Error())
+		return problems
+	}
+	err = json.Unmarshal(fileContents, &descriptor)
+	if err != nil {
+		report("", "Invalid JSON: %s", err.Error())
+		return problems
+	}
+
+	// Authentication method
+	if value, exists := descriptor["authenticationMethod"]; exists {
+		authenticationMethod, ok := value.(string)
+		if !ok || indexOfString(validAuthenticationMethods, authenticationMethod) < 0 {
+			report("authenticationMethod", "Value %v should be one of: %s", toJSON(value), strings.Join(validAuthenticationMethods, ", "))
+		}
+	}
+
+	// Welcome file
+	if value, exists := descriptor["welcomeFile"]; exists {
+		welcomeFile, ok := value.(string)
+		if !ok || welcomeFile == "" {
+			report("welcomeFile", "Value should be a non-empty string")
+		} else if welcomeFilePath := getWelcomeFilePath(welcomeFile); welcomeFilePath != "" && !appFileExists(appPath, welcomeFilePath) {
+			warn("welcomeFile", "File '%s' does not exist in application. Make sure it is served by a route", welcomeFilePath)
+		}
+	}
+
+	// Routes
+	if value, exists := descriptor["routes"]; exists {
+		routes, ok := value.([]interface{})
+		if !ok {
+			report("routes", "Value should be an array")
+			return problems
+		}
+		for idx, value := range routes {
+			routePath := fmt.Sprintf("routes[%d]", idx)
+			route, ok := value.(map[string]interface{})
+			if !ok {
+				report(routePath, "Value should be an object")
+				continue
+			}
+
+			// Route source
+			source := route["source"]
+			if sourceObject, ok := source.(map[string]interface{}); ok {
+				source = sourceObject["path"]
+				routePath = routePath + "/source/path"
+			} else {
+				routePath = routePath + "/source"
+			}
+			if source == nil {
+				report(routePath, "Required string is missing")
+			} else if sourceString, ok := source.(string); !ok {
+				report(routePath, "Value should be a string")
+			} else if err := validateRouteSource(sourceString); err != nil {
+				report(routePath, "Value '%s' is not a valid regular expression: %s", sourceString, err.Error())
+			}
+			routePath = fmt.Sprintf("routes[%d]", idx)
+
+			// Authentication type
+			if value, exists := route["authenticationType"]; exists {
+				authenticationType, ok := value.(string)
+				if !ok || indexOfString(validAuthenticationTypes, authenticationType) < 0 {
+					report(routePath+"/authenticationType", "Value %v should be one of: %s", toJSON(value), strings.Join(validAuthenticationTypes, ", "))
+				}
+			}
+
+			// Scopes
+			if value, exists := route["scope"]; exists {
+				switch scope := value.(type) {
+				case map[string]interface{}:
+					verbs := make([]string, 0)
+					for verb := range scope {
+						verbs = append(verbs, verb)
+					}
+					sort.Strings(verbs)
+					for _, verb := range verbs {
+						if indexOfString(validScopeVerbs, verb) < 0 {
+							report(routePath+"/scope/"+verb, "Key should be one of: %s", strings.Join(validScopeVerbs, ", "))
+							continue
+						}
+						problems = append(problems, validateScopes(fileName, routePath+"/scope/"+verb, scope[verb])...)
+					}
+				default:
+					problems = append(problems, validateScopes(fileName, routePath+"/scope", value)...)
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// validateScopes validates scope value (string or array of strings)
+func validateScopes(fileName string, path string, value interface{}) []ValidationProblem {
+	problems := make([]ValidationProblem, 0)
+	scopes := make([]interface{}, 0)
+	switch scope := value.(type) {
+	case string:
+		scopes = append(scopes, scope)
+	case []interface{}:
+		scopes = scope
+	default:
+		return append(problems, ValidationProblem{File: fileName, Path: path, Message: "Value should be a string or an array of strings"})
+	}
+	for idx, scope := range scopes {
+		scopePath := path
+		if _, ok := value.([]interface{}); ok {
+			scopePath = fmt.Sprintf("%s[%d]", path, idx)
+		}
+		scopeString, ok := scope.(string)
+		if !ok {
+			problems = append(problems, ValidationProblem{File: fileName, Path: scopePath, Message: "Value should be a string"})
+		} else if !scopeRegexp.MatchString(scopeString) {
+			problems = append(problems, ValidationProblem{
+				File:    fileName,
+				Path:    scopePath,
+				Message: fmt.Sprintf("Value '%s' is not a valid scope. Expected format is '$XSAPPNAME.<scope>'", scopeString),
+			})
+		}
+	}
+	return problems
+}
+
+// validateRouteSource checks that route source is a valid regular expression.
+// Constructs of JavaScript regular expressions not supported by Go
+// (lookarounds and backreferences) are not reported as problems
+func validateRouteSource(source string) error {
+	_, err := regexp.Compile(source)
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(source, "(?=") || strings.Contains(source, "(?!") || strings.Contains(source, "(?<") ||
+		regexp.MustCompile(`\\[1-9]`).MatchString(source) {
+		log.Tracef("Skipping validation of route source '%s' with JavaScript specific constructs\n", source)
+		return nil
+	}
+	return err
+}
+
+// getWelcomeFilePath returns path of welcome file relative to application root
+func getWelcomeFilePath(welcomeFile string) string {
+	welcomeFile = strings.SplitN(welcomeFile, "?", 2)[0]
+	welcomeFile = strings.SplitN(welcomeFile, "#", 2)[0]
+	welcomeFile = strings.TrimPrefix(welcomeFile, "/")
+	if strings.HasSuffix(welcomeFile, "/") {
+		welcomeFile = welcomeFile + "index.html"
+	}
+	return welcomeFile
+}
+
+// appFileExists checks if file exists in application directory or archive
+func appFileExists(appPath string, fileName string) bool {
+	if !isAppArchive(appPath) {
+		_, err := os.Stat(appPath + slash + strings.Replace(fileName, "/", slash, -1))
+		return err == nil
+	}
+	archive, err := zip.OpenReader(appPath)
+	if err != nil {
+		return false
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.Name == fileName {
+			return true
+		}
+	}
+	return false
+}
+
+// toJSON returns JSON representation of value for messages
+func toJSON(value interface{}) string {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// expectedProblem path and severity of expected validation problem
+type expectedProblem struct {
+	Path    string
+	Warning bool
+}
+
+// writeTestApp creates application directory with given files
+func writeTestApp(t *testing.T, files map[string]string) string {
+	appPath, err := ioutil.TempDir("", "html5-validate-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		filePath := filepath.Join(appPath, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return appPath
+}
+
+// checkProblems compares validation problems with expected ones
+func checkProblems(t *testing.T, name string, problems []ValidationProblem, expected []expectedProblem) {
+	if len(problems) != len(expected) {
+		t.Errorf("%s: got %d problems %+v, expected %+v", name, len(problems), problems, expected)
+		return
+	}
+	for idx, problem := range problems {
+		if problem.Path != expected[idx].Path || problem.Warning != expected[idx].Warning {
+			t.Errorf("%s: problem #%d is %+v, expected %+v", name, idx, problem, expected[idx])
+		}
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expected []expectedProblem
+	}{
+		{
+			name:     "valid",
+			manifest: `{"sap.app": {"id": "my.app", "applicationVersion": {"version": "1.0.0"}}, "sap.cloud": {"service": "my.service", "public": true}}`,
+		},
+		{
+			name:     "missing file",
+			expected: []expectedProblem{{Path: ""}},
+		},
+		{
+			name:     "invalid JSON",
+			manifest: `{"sap.app": `,
+			expected: []expectedProblem{{Path: ""}},
+		},
+		{
+			name:     "missing sap.app",
+			manifest: `{}`,
+			expected: []expectedProblem{{Path: "sap.app"}},
+		},
+		{
+			name:     "missing id and version",
+			manifest: `{"sap.app": {}}`,
+			expected: []expectedProblem{{Path: "sap.app/id"}, {Path: "sap.app/applicationVersion"}},
+		},
+		{
+			name:     "invalid id",
+			manifest: `{"sap.app": {"id": "my app", "applicationVersion": {"version": "1.0.0"}}}`,
+			expected: []expectedProblem{{Path: "sap.app/id"}},
+		},
+		{
+			name:     "id with underscore only",
+			manifest: `{"sap.app": {"id": "_.-", "applicationVersion": {"version": "1.0.0"}}}`,
+		},
+		{
+			name:     "id of dots",
+			manifest: `{"sap.app": {"id": "..", "applicationVersion": {"version": "1.0.0"}}}`,
+			expected: []expectedProblem{{Path: "sap.app/id"}},
+		},
+		{
+			name:     "empty version",
+			manifest: `{"sap.app": {"id": "app", "applicationVersion": {"version": ""}}}`,
+			expected: []expectedProblem{{Path: "sap.app/applicationVersion/version"}},
+		},
+		{
+			name:     "tolerant version",
+			manifest: `{"sap.app": {"id": "app", "applicationVersion": {"version": "v1.0"}}}`,
+		},
+		{
+			name:     "non-semantic version",
+			manifest: `{"sap.app": {"id": "app", "applicationVersion": {"version": "latest"}}}`,
+			expected: []expectedProblem{{Path: "sap.app/applicationVersion/version", Warning: true}},
+		},
+		{
+			name:     "invalid sap.cloud",
+			manifest: `{"sap.app": {"id": "app", "applicationVersion": {"version": "1.0.0"}}, "sap.cloud": []}`,
+			expected: []expectedProblem{{Path: "sap.cloud"}},
+		},
+		{
+			name:     "invalid service and public",
+			manifest: `{"sap.app": {"id": "app", "applicationVersion": {"version": "1.0.0"}}, "sap.cloud": {"service": "my..service", "public": "yes"}}`,
+			expected: []expectedProblem{{Path: "sap.cloud/service"}, {Path: "sap.cloud/public"}},
+		},
+	}
+	for _, test := range tests {
+		files := map[string]string{}
+		if test.manifest != "" {
+			files["manifest.json"] = test.manifest
+		}
+		appPath := writeTestApp(t, files)
+		defer os.RemoveAll(appPath)
+		checkProblems(t, test.name, validateManifest(appPath, nil), test.expected)
+	}
+}
+
+func TestValidateAppDescriptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor string
+		files      map[string]string
+		expected   []expectedProblem
+	}{
+		{
+			name:       "valid",
+			descriptor: `{"authenticationMethod": "route", "welcomeFile": "/index.html", "routes": [{"source": "^/api/(.*)$", "destination": "api", "authenticationType": "xsuaa", "scope": "$XSAPPNAME.Display"}]}`,
+			files:      map[string]string{"index.html": ""},
+		},
+		{
+			name:     "missing file",
+			expected: []expectedProblem{{Path: ""}},
+		},
+		{
+			name:       "invalid authentication method",
+			descriptor: `{"authenticationMethod": "basic"}`,
+			expected:   []expectedProblem{{Path: "authenticationMethod"}},
+		},
+		{
+			name:       "empty welcome file",
+			descriptor: `{"welcomeFile": ""}`,
+			expected:   []expectedProblem{{Path: "welcomeFile"}},
+		},
+		{
+			name:       "missing welcome file",
+			descriptor: `{"welcomeFile": "/index.html"}`,
+			expected:   []expectedProblem{{Path: "welcomeFile", Warning: true}},
+		},
+		{
+			name:       "welcome folder",
+			descriptor: `{"welcomeFile": "webapp/?sap-language=EN"}`,
+			files:      map[string]string{"webapp/index.html": ""},
+		},
+		{
+			name:       "routes not array",
+			descriptor: `{"routes": {}}`,
+			expected:   []expectedProblem{{Path: "routes"}},
+		},
+		{
+			name:       "invalid routes",
+			descriptor: `{"routes": ["/", {}, {"source": 1}, {"source": {"path": "(a"}}, {"source": "(?!x)a", "authenticationType": "saml"}]}`,
+			expected: []expectedProblem{
+				{Path: "routes[0]"},
+				{Path: "routes[1]/source"},
+				{Path: "routes[2]/source"},
+				{Path: "routes[3]/source/path"},
+				{Path: "routes[4]/authenticationType"},
+			},
+		},
+		{
+			name:       "invalid scopes",
+			descriptor: `{"routes": [{"source": "^/", "scope": {"GET": ["$XSAPPNAME.Read", 1], "FETCH": "x", "default": "not a scope"}}, {"source": "^/", "scope": 1}]}`,
+			expected: []expectedProblem{
+				{Path: "routes[0]/scope/FETCH"},
+				{Path: "routes[0]/scope/GET[1]"},
+				{Path: "routes[0]/scope/default"},
+				{Path: "routes[1]/scope"},
+			},
+		},
+	}
+	for _, test := range tests {
+		files := map[string]string{}
+		for name, content := range test.files {
+			files[name] = content
+		}
+		if test.descriptor != "" {
+			files["xs-app.json"] = test.descriptor
+		}
+		appPath := writeTestApp(t, files)
+		defer os.RemoveAll(appPath)
+		checkProblems(t, test.name, validateAppDescriptor(appPath, nil), test.expected)
+	}
+}
+
+func TestSplitValidationProblems(t *testing.T) {
+	problems := []ValidationProblem{
+		{Path: "a"},
+		{Path: "b", Warning: true},
+		{Path: "c"},
+	}
+	errors, warnings := splitValidationProblems(problems)
+	if len(errors) != 2 || errors[0].Path != "a" || errors[1].Path != "c" {
+		t.Errorf("Errors are %+v, expected a and c", errors)
+	}
+	if len(warnings) != 1 || warnings[0].Path != "b" {
+		t.Errorf("Warnings are %+v, expected b", warnings)
+	}
+}
@@ -31,6 +31,11 @@ var Commands = []commands.Command{
 	&commands.PushCommand{},
 	&commands.DeleteCommand{},
 	&commands.InfoCommand{},
+	&commands.ValidateCommand{},
+	&commands.PruneCommand{},
+	&commands.RollbackCommand{},
+	&commands.ApplyCommand{},
+	&commands.SyncCommand{},
 }
 
 // Run runs this plugin
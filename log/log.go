@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"time"
@@ -16,6 +17,9 @@ var DebugSensitive = (os.Getenv("DEBUG") == "2" || os.Getenv("DEBUG") == "3")
 // DebugResponse true if environment variable DEBUG is set to 3
 var DebugResponse = os.Getenv("DEBUG") == "3"
 
+// output writer of printed values
+var output io.Writer = os.Stdout
+
 // Sensitive is a wrapper around any sensitive data
 // that should not be logged, except the corresponding
 // flag is set to true
@@ -102,19 +106,29 @@ func Fatalln(v ...interface{}) {
 	exiter.Exit(1)
 }
 
+// SetOutput sets writer of printed values. Returned
+// function restores previous writer
+func SetOutput(writer io.Writer) func() {
+	previousOutput := output
+	output = writer
+	return func() {
+		output = previousOutput
+	}
+}
+
 // Print prints value
 func Print(v ...interface{}) {
-	fmt.Print(v...)
+	fmt.Fprint(output, v...)
 }
 
 // Printf prints formatted value
 func Printf(format string, v ...interface{}) {
-	fmt.Printf(format, v...)
+	fmt.Fprintf(output, format, v...)
 }
 
 // Println print value line
 func Println(v ...interface{}) {
-	fmt.Println(v...)
+	fmt.Fprintln(output, v...)
 }
 
 // Trace print value if Debug flag is on
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/cli/cf/terminal"
+)
+
+// printer prints messages to switchable output and optionally
+// saves them to output bucket
+type printer struct {
+	output                io.Writer
+	disableTerminalOutput bool
+	outputBucket          *[]string
+}
+
+// Print print values
+func (p *printer) Print(values ...interface{}) (n int, err error) {
+	return p.print(false, fmt.Sprint(values...))
+}
+
+// Printf print formatted values
+func (p *printer) Printf(format string, a ...interface{}) (n int, err error) {
+	return p.print(false, fmt.Sprintf(format, a...))
+}
+
+// Println print values line
+func (p *printer) Println(values ...interface{}) (n int, err error) {
+	return p.print(false, fmt.Sprint(values...)+"\n")
+}
+
+// ForcePrint print values, even if terminal output is disabled
+func (p *printer) ForcePrint(values ...interface{}) (n int, err error) {
+	return p.print(true, fmt.Sprint(values...))
+}
+
+// ForcePrintf print formatted values, even if terminal output is disabled
+func (p *printer) ForcePrintf(format string, a ...interface{}) (n int, err error) {
+	return p.print(true, fmt.Sprintf(format, a...))
+}
+
+// ForcePrintln print values line, even if terminal output is disabled
+func (p *printer) ForcePrintln(values ...interface{}) (n int, err error) {
+	return p.print(true, fmt.Sprint(values...)+"\n")
+}
+
+// print save message to output bucket and write it to output
+func (p *printer) print(force bool, message string) (n int, err error) {
+	if p.outputBucket != nil {
+		*p.outputBucket = append(*p.outputBucket, terminal.Decolorize(message))
+	}
+	if p.disableTerminalOutput && !force {
+		return
+	}
+	return io.WriteString(p.output, message)
+}
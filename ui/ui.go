@@ -1,37 +1,63 @@
 package ui
 
 import (
+	"cf-html5-apps-repo-cli-plugin/log"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/cli/cf/terminal"
 )
 
-var teePrinter *terminal.TeePrinter
+var outputPrinter *printer
 var ui terminal.UI
 
 func init() {
 	i18n.T = func(translationID string, args ...interface{}) string {
 		return translationID
 	}
-	teePrinter = terminal.NewTeePrinter()
-	ui = terminal.NewUI(os.Stdin, teePrinter)
-	teePrinter.DisableTerminalOutput(false)
+	outputPrinter = &printer{output: os.Stdout}
+	ui = terminal.NewUI(os.Stdin, outputPrinter)
 }
 
 // SetOutputBucket set output bucket
 func SetOutputBucket(bucket *[]string) {
-	teePrinter.SetOutputBucket(bucket)
+	outputPrinter.outputBucket = bucket
 }
 
 // ClearOutputBucket clear output bucket
 func ClearOutputBucket() {
-	teePrinter.SetOutputBucket(nil)
+	outputPrinter.outputBucket = nil
 }
 
 // DisableTerminalOutput disable terminal output
 func DisableTerminalOutput(disable bool) {
-	teePrinter.DisableTerminalOutput(disable)
+	outputPrinter.disableTerminalOutput = disable
+}
+
+// SetOutput set writer of messages and traces. Returned
+// function restores previous writer
+func SetOutput(output io.Writer) func() {
+	previousOutput := outputPrinter.output
+	outputPrinter.output = output
+	restoreLog := log.SetOutput(output)
+	return func() {
+		outputPrinter.output = previousOutput
+		restoreLog()
+	}
+}
+
+// RedirectToStderr print output to standard error,
+// so standard output can be used for command data.
+// Returned function restores previous output
+func RedirectToStderr() func() {
+	return SetOutput(os.Stderr)
+}
+
+// Stdout standard output for command data
+func Stdout() io.Writer {
+	return os.Stdout
 }
 
 // PrintPaginator print paginator
@@ -46,7 +72,11 @@ func Say(message string, args ...interface{}) {
 
 // PrintCapturingNoOutput print capturing no output
 func PrintCapturingNoOutput(message string, args ...interface{}) {
-	ui.PrintCapturingNoOutput(message, args...)
+	if len(args) == 0 {
+		fmt.Fprintf(outputPrinter.output, "%s", message)
+	} else {
+		fmt.Fprintf(outputPrinter.output, message, args...)
+	}
 }
 
 // Warn warning
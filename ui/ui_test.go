@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	var first, second bytes.Buffer
+	restoreFirst := SetOutput(&first)
+	Say("first %d", 1)
+	restoreSecond := SetOutput(&second)
+	Say("second")
+	PrintCapturingNoOutput("no %s", "newline")
+	restoreSecond()
+	Say("first again")
+	restoreFirst()
+
+	if first.String() != "first 1\nfirst again\n" {
+		t.Errorf("First output is %q", first.String())
+	}
+	if second.String() != "second\nno newline" {
+		t.Errorf("Second output is %q", second.String())
+	}
+	if outputPrinter.output != os.Stdout {
+		t.Errorf("Output is not restored to standard output")
+	}
+}
+
+func TestOutputBucket(t *testing.T) {
+	var output bytes.Buffer
+	defer SetOutput(&output)()
+	bucket := make([]string, 0)
+	SetOutputBucket(&bucket)
+	defer ClearOutputBucket()
+	DisableTerminalOutput(true)
+	defer DisableTerminalOutput(false)
+
+	Say("hidden")
+	outputPrinter.ForcePrintln("forced")
+
+	if output.String() != "forced\n" {
+		t.Errorf("Output is %q, expected only forced message", output.String())
+	}
+	if expected := []string{"hidden\n", "forced\n"}; !reflect.DeepEqual(bucket, expected) {
+		t.Errorf("Output bucket is %q, expected %q", bucket, expected)
+	}
+}